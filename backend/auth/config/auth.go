@@ -0,0 +1,176 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AuthConfig は認証まわりの設定値を保持する
+type AuthConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	CookieSecure    bool
+	JWTKeysDir      string
+	JWTActiveKID    string
+	JWTIssuer       string
+	JWTAudience     string
+	JWTAllowedAlgs  []string
+	JWTClockSkew    time.Duration
+
+	AppBaseURL               string
+	RequireEmailVerification bool
+	EmailVerificationTTL     time.Duration
+	EmailResendInterval      time.Duration
+	PasswordResetTTL         time.Duration
+
+	TOTPIssuer    string
+	MFAPendingTTL time.Duration
+
+	LoginAttemptStore     string
+	LoginMaxFailures      int
+	LoginMaxFailuresPerIP int
+	LoginBackoffBase      time.Duration
+	LoginLockoutDuration  time.Duration
+	LoginFailureWindow    time.Duration
+	AdminEmails           []string
+	DefaultRole           string
+	TrustedProxies        []string
+
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordBannedFile    string
+	PasswordHistorySize   int
+	PasswordBreachedDir   string
+
+	PasswordHashAlgorithm string
+	Argon2Memory          int
+	Argon2Iterations      int
+	Argon2Parallelism     int
+	BcryptCost            int
+
+	OIDCProviders         []OIDCProviderConfig
+	OIDCAuthRequestTTL    time.Duration
+	OIDCPostLoginRedirect string
+}
+
+var Auth AuthConfig
+
+// LoadAuthConfig は環境変数から認証設定を読み込む
+func LoadAuthConfig() {
+	Auth = AuthConfig{
+		AccessTokenTTL:  getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+		CookieSecure:    getBoolEnv("COOKIE_SECURE", false),
+		JWTKeysDir:      os.Getenv("JWT_KEYS_DIR"),
+		JWTActiveKID:    os.Getenv("JWT_ACTIVE_KID"),
+		JWTIssuer:       getStringEnv("JWT_ISSUER", "auth-service"),
+		JWTAudience:     getStringEnv("JWT_AUDIENCE", "incident-api"),
+		JWTAllowedAlgs:  getListEnv("JWT_ALLOWED_ALGS", []string{"RS256", "EdDSA"}),
+		JWTClockSkew:    getDurationEnv("JWT_CLOCK_SKEW", 30*time.Second),
+
+		AppBaseURL:               getStringEnv("APP_BASE_URL", "http://localhost:3000"),
+		RequireEmailVerification: getBoolEnv("REQUIRE_EMAIL_VERIFICATION", false),
+		EmailVerificationTTL:     getDurationEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		EmailResendInterval:      getDurationEnv("EMAIL_RESEND_INTERVAL", time.Minute),
+		PasswordResetTTL:         getDurationEnv("PASSWORD_RESET_TTL", time.Hour),
+
+		TOTPIssuer:    getStringEnv("TOTP_ISSUER", "Incident Manager"),
+		MFAPendingTTL: getDurationEnv("MFA_PENDING_TTL", 5*time.Minute),
+
+		LoginAttemptStore:     getStringEnv("LOGIN_ATTEMPT_STORE", "postgres"),
+		LoginMaxFailures:      getIntEnv("LOGIN_MAX_FAILURES", 5),
+		LoginMaxFailuresPerIP: getIntEnv("LOGIN_MAX_FAILURES_PER_IP", 20),
+		LoginBackoffBase:      getDurationEnv("LOGIN_BACKOFF_BASE", time.Second),
+		LoginLockoutDuration:  getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginFailureWindow:    getDurationEnv("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		AdminEmails:           getListEnv("ADMIN_EMAILS", nil),
+		DefaultRole:           getStringEnv("DEFAULT_ROLE", "viewer"),
+		TrustedProxies:        getListEnv("TRUSTED_PROXIES", nil),
+
+		PasswordMinLength:     getIntEnv("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:  getBoolEnv("PASSWORD_REQUIRE_UPPER", false),
+		PasswordRequireLower:  getBoolEnv("PASSWORD_REQUIRE_LOWER", false),
+		PasswordRequireDigit:  getBoolEnv("PASSWORD_REQUIRE_DIGIT", false),
+		PasswordRequireSymbol: getBoolEnv("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordBannedFile:    os.Getenv("PASSWORD_BANNED_FILE"),
+		PasswordHistorySize:   getIntEnv("PASSWORD_HISTORY_SIZE", 5),
+		PasswordBreachedDir:   os.Getenv("PASSWORD_BREACHED_DIR"),
+
+		PasswordHashAlgorithm: getStringEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		Argon2Memory:          getIntEnv("ARGON2_MEMORY_KIB", 64*1024),
+		Argon2Iterations:      getIntEnv("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism:     getIntEnv("ARGON2_PARALLELISM", 2),
+		BcryptCost:            getIntEnv("BCRYPT_COST", 10),
+	}
+
+	Auth.OIDCProviders = loadOIDCProviders()
+	Auth.OIDCAuthRequestTTL = getDurationEnv("OIDC_AUTH_REQUEST_TTL", 10*time.Minute)
+	Auth.OIDCPostLoginRedirect = getStringEnv("OIDC_POST_LOGIN_REDIRECT", Auth.AppBaseURL+"/login/callback")
+}
+
+func getStringEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getListEnv(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getIntEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("環境変数 %s の値が不正です: %v", key, err)
+	}
+	return n
+}
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("環境変数 %s の値が不正です: %v", key, err)
+	}
+	return d
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("環境変数 %s の値が不正です: %v", key, err)
+	}
+	return b
+}
@@ -25,14 +25,19 @@ func ConnectDatabase() {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		dbHost, dbUser, dbPassword, dbName, dbPort)
 
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatal("データベース接続に失敗しました:", err)
 	}
 
 	// マイグレーション
-	err = DB.AutoMigrate(&models.User{})
+	err = DB.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.OneTimeToken{}, &models.RecoveryCode{}, &models.LoginAttempt{}, &models.Role{}, &models.Permission{}, &models.UserIdentity{}, &models.OIDCAuthRequest{}, &models.PasswordHistory{})
 	if err != nil {
 		log.Fatal("マイグレーションに失敗しました:", err)
 	}
+
+	// 既定のロールと権限
+	if err := seedRoles(DB); err != nil {
+		log.Fatal("ロールの初期化に失敗しました:", err)
+	}
 }
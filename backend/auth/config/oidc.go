@@ -0,0 +1,40 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+// OIDCProviderConfig は外部 IdP（OpenID Connect）ごとの設定
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// loadOIDCProviders は OIDC_PROVIDERS に列挙された名前ごとに
+// OIDC_<NAME>_ISSUER などの環境変数から設定を読み込む
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getListEnv("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       getListEnv(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			log.Fatalf("OIDCプロバイダ %s の設定が不足しています（%sISSUER, %sCLIENT_ID, %sREDIRECT_URL）", name, prefix, prefix, prefix)
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
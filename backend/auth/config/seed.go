@@ -0,0 +1,101 @@
+package config
+
+import (
+	"main/models"
+
+	"gorm.io/gorm"
+)
+
+var defaultPermissions = []models.Permission{
+	{Name: models.PermissionIncidentRead, Description: "インシデントの閲覧"},
+	{Name: models.PermissionIncidentWrite, Description: "インシデントと対応履歴の作成・更新"},
+	{Name: models.PermissionIncidentDelete, Description: "インシデントの削除"},
+	{Name: models.PermissionUserAdmin, Description: "ユーザーとロールの管理"},
+}
+
+var defaultRoles = map[string][]string{
+	models.RoleAdmin: {
+		models.PermissionIncidentRead,
+		models.PermissionIncidentWrite,
+		models.PermissionIncidentDelete,
+		models.PermissionUserAdmin,
+	},
+	models.RoleResponder: {
+		models.PermissionIncidentRead,
+		models.PermissionIncidentWrite,
+	},
+	models.RoleViewer: {
+		models.PermissionIncidentRead,
+	},
+}
+
+// seedRoles は既定の権限とロールを作成し、ADMIN_EMAILS のユーザーに admin ロールを付与する。
+// 既存のロールの権限は管理画面での変更を優先して上書きしない。
+func seedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions := make(map[string]models.Permission)
+		for _, p := range defaultPermissions {
+			permission := p
+			if err := tx.Where(models.Permission{Name: p.Name}).Attrs(models.Permission{Description: p.Description}).
+				FirstOrCreate(&permission).Error; err != nil {
+				return err
+			}
+			permissions[p.Name] = permission
+		}
+
+		for name, names := range defaultRoles {
+			// 以前の論理削除で残ったロールも一意インデックスに残るため、削除済みも含めて検索する
+			var role models.Role
+			result := tx.Unscoped().Where(models.Role{Name: name}).FirstOrCreate(&role)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 && !role.DeletedAt.Valid {
+				continue
+			}
+			if role.DeletedAt.Valid {
+				if err := tx.Unscoped().Model(&role).Update("deleted_at", nil).Error; err != nil {
+					return err
+				}
+				role.DeletedAt = gorm.DeletedAt{}
+			}
+
+			rolePermissions := make([]models.Permission, len(names))
+			for i, n := range names {
+				rolePermissions[i] = permissions[n]
+			}
+			if err := tx.Model(&role).Association("Permissions").Replace(rolePermissions); err != nil {
+				return err
+			}
+		}
+
+		if len(Auth.AdminEmails) == 0 {
+			return nil
+		}
+
+		var admin models.Role
+		if err := tx.Where("name = ?", models.RoleAdmin).First(&admin).Error; err != nil {
+			return err
+		}
+
+		var users []models.User
+		if err := tx.Where("email IN ?", Auth.AdminEmails).Find(&users).Error; err != nil {
+			return err
+		}
+		for i := range users {
+			if err := tx.Model(&users[i]).Association("Roles").Append(&admin); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// IsProtectedRole は削除や名前の変更を許可しないロールかどうかを判定する。
+// 既定のロールは起動時に再作成され、DEFAULT_ROLE は新規ユーザーへの付与に使われる。
+func IsProtectedRole(name string) bool {
+	if _, ok := defaultRoles[name]; ok {
+		return true
+	}
+	return name == Auth.DefaultRole
+}
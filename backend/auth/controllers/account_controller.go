@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/config"
+	"main/logger"
+	"main/mail"
+	"main/models"
+	"main/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var errEmailTaken = errors.New("email already registered")
+
+// checkCurrentPassword は本人確認のため現在のパスワードを検証する。失敗時はレスポンスを書き込んで false を返す。
+// パスワードを持たない OIDC のみのユーザーは、パスワード再設定で先にパスワードを設定する必要がある。
+// 盗まれたアクセストークンで総当たりされないよう、ログインと同じ試行回数の制限を適用する。
+func checkCurrentPassword(c *gin.Context, user *models.User, password string) bool {
+	if rejectLockedLogin(c, user.Email) {
+		return false
+	}
+
+	if matched, _, _ := utils.VerifyPassword(user.Password, password); !matched {
+		logger.Log.Info("Incorrect current password", zap.Uint("userID", user.ID))
+		recordLoginFailure(c, user.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "現在のパスワードが間違っています"})
+		return false
+	}
+	return true
+}
+
+// sendEmailChangeEmail は新しいメールアドレスに確認用トークンを送る
+func sendEmailChangeEmail(ctx context.Context, user *models.User, newEmail string) error {
+	token, err := issueOneTimeToken(config.DB, user.ID, models.TokenPurposeEmailChange, config.Auth.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/confirm-email-change?token=%s", config.Auth.AppBaseURL, url.QueryEscape(token))
+	return mail.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "メールアドレス変更の確認",
+		Body:    fmt.Sprintf("以下のリンクからメールアドレスの変更を確定してください。\n\n%s\n\nこのリンクの有効期限は%sです。心当たりがない場合はこのメールを破棄してください。\n", link, config.Auth.EmailVerificationTTL),
+	})
+}
+
+// respondWithRotatedTokens は他のセッションを無効化した後、現在のクライアント用に新しいトークンを発行して返す
+func respondWithRotatedTokens(c *gin.Context, userID uint, message string) {
+	tokens, err := issueTokenPair(config.DB, userID)
+	if err != nil {
+		logger.Log.Error("Failed to generate token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの生成に失敗しました"})
+		return
+	}
+
+	setRefreshTokenCookie(c, tokens.RefreshToken)
+	c.JSON(http.StatusOK, gin.H{
+		"message":       message,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(config.Auth.AccessTokenTTL.Seconds()),
+	})
+}
+
+func ChangePassword(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for password change", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if !checkCurrentPassword(c, user, input.CurrentPassword) {
+		return
+	}
+
+	violations, err := validateNewPassword(c.Request.Context(), config.DB, "new_password", input.NewPassword, user.Email, user.ID)
+	if err != nil {
+		logger.Log.Error("Failed to validate password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+	if len(violations) > 0 {
+		logger.Log.Info("New password rejected by policy", zap.Uint("userID", user.ID))
+		respondPasswordViolations(c, violations)
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(input.NewPassword)
+	if err != nil {
+		logger.Log.Error("Failed to hash password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードのハッシュ化に失敗しました"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updatePassword(tx, user.ID, hashedPassword); err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, user.ID)
+	})
+	if err != nil {
+		logger.Log.Error("Failed to change password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("Password changed", zap.Uint("userID", user.ID))
+	respondWithRotatedTokens(c, user.ID, "パスワードを変更しました")
+}
+
+// ChangeEmail は新しいメールアドレスに確認メールを送る。確認されるまで現在のアドレスは変わらない。
+func ChangeEmail(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+		NewEmail string `json:"new_email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for email change", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	newEmail := strings.TrimSpace(input.NewEmail)
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if !checkCurrentPassword(c, user, input.Password) {
+		return
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "現在と同じメールアドレスです"})
+		return
+	}
+
+	taken, err := emailTaken(config.DB, newEmail)
+	if err != nil {
+		logger.Log.Error("Database error during email change", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+	if taken {
+		logger.Log.Info("Email change to existing email", zap.Uint("userID", user.ID))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "このメールアドレスは既に登録されています"})
+		return
+	}
+
+	if err := config.DB.Model(user).Update("pending_email", newEmail).Error; err != nil {
+		logger.Log.Error("Failed to save pending email", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	if err := sendEmailChangeEmail(c.Request.Context(), user, newEmail); err != nil {
+		logger.Log.Error("Failed to send email change confirmation", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "確認メールの送信に失敗しました"})
+		return
+	}
+
+	logger.Log.Info("Email change requested", zap.Uint("userID", user.ID), zap.String("newEmail", newEmail))
+	c.JSON(http.StatusOK, gin.H{"message": "新しいメールアドレスに確認メールを送信しました"})
+}
+
+// ConfirmEmailChange は確認用トークンを検証してメールアドレスを変更し、全てのセッションを無効化する
+func ConfirmEmailChange(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for email change confirmation", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	var oldEmail string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeOneTimeToken(tx, input.Token, models.TokenPurposeEmailChange)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return err
+		}
+		if user.PendingEmail == "" {
+			return errInvalidOneTimeToken
+		}
+
+		// 確認待ちの間に他のユーザーが登録した場合に備えて再確認する
+		taken, err := emailTaken(tx, user.PendingEmail)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return errEmailTaken
+		}
+
+		oldEmail = user.Email
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":         user.PendingEmail,
+			"pending_email": "",
+			"verified_at":   time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, user.ID)
+	})
+	if errors.Is(err, errInvalidOneTimeToken) {
+		logger.Log.Info("Invalid or expired email change token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "確認リンクが無効か、有効期限が切れています"})
+		return
+	}
+	if errors.Is(err, errEmailTaken) || errors.Is(err, gorm.ErrDuplicatedKey) {
+		logger.Log.Info("Email change target already registered", zap.Uint("userID", user.ID))
+		c.JSON(http.StatusConflict, gin.H{"error": "このメールアドレスは既に登録されています"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to change email", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	// 旧アドレスにも通知し、本人以外による変更に気付けるようにする
+	if err := mail.Send(c.Request.Context(), mail.Message{
+		To:      oldEmail,
+		Subject: "メールアドレスが変更されました",
+		Body:    fmt.Sprintf("アカウントのメールアドレスが %s に変更されました。心当たりがない場合は管理者に連絡してください。\n", user.Email),
+	}); err != nil {
+		logger.Log.Error("Failed to send email change notice", zap.Error(err))
+	}
+
+	logger.Log.Info("Email changed", zap.Uint("userID", user.ID), zap.String("oldEmail", oldEmail), zap.String("newEmail", user.Email))
+	clearRefreshTokenCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "メールアドレスを変更しました。もう一度ログインしてください"})
+}
+
+// DeleteAccount は本人のアカウントを論理削除し、全てのセッションを無効化する
+func DeleteAccount(c *gin.Context) {
+	var input struct {
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for account deletion", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if !checkCurrentPassword(c, user, input.Password) {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := revokeAllSessions(tx, user.ID); err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		logger.Log.Error("Failed to delete account", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("Account deleted by user", zap.Uint("userID", user.ID))
+	clearRefreshTokenCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "アカウントを削除しました"})
+}
+
+// emailTaken は削除済みを含めてメールアドレスが使われているか判定する
+func emailTaken(db *gorm.DB, email string) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&models.User{}).Where("LOWER(email) = LOWER(?)", email).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
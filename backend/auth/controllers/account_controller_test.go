@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"main/models"
+	"main/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestChangePasswordLocksOutRepeatedFailures(t *testing.T) {
+	db := setupTestEnv(t)
+	if err := utils.InitPasswordHashers("bcrypt", utils.Argon2idHasher{}, 4); err != nil {
+		t.Fatalf("failed to initialize password hashers: %v", err)
+	}
+	hash, err := utils.HashPassword("current-password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user := models.User{Email: "user@example.com", Password: hash}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/user/password", func(c *gin.Context) { c.Set("userID", user.ID) }, ChangePassword)
+	changePassword := func(current string) *httptest.ResponseRecorder {
+		body := `{"current_password":"` + current + `","new_password":"new-password-123"}`
+		req := httptest.NewRequest(http.MethodPost, "/user/password", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := changePassword("wrong-password"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+	// 失敗が記録され、正しいパスワードでも待ち時間の間は拒否される
+	if w := changePassword("current-password"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status after a failure = %d, want %d: %s", w.Code, http.StatusTooManyRequests, w.Body.String())
+	}
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"errors"
 	"main/config"
+	"main/lockout"
 	"main/logger"
 	"main/models"
 	"main/utils"
@@ -10,14 +11,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 func Register(c *gin.Context) {
 	var input struct {
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
+		Password string `json:"password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -28,7 +28,8 @@ func Register(c *gin.Context) {
 
 	// メールアドレスの存在確認
 	var existingUser models.User
-	if err := config.DB.Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
+	// 削除済みのユーザーもメールアドレスの一意制約に含まれるため Unscoped で確認する
+	if err := config.DB.Unscoped().Where("email = ?", input.Email).First(&existingUser).Error; err == nil {
 		logger.Log.Info("Attempt to register with existing email", zap.String("email", input.Email))
 		c.JSON(http.StatusBadRequest, gin.H{"error": "このメールアドレスは既に登録されています"})
 		return
@@ -38,8 +39,21 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	// パスワードポリシーの検証
+	violations, err := validateNewPassword(c.Request.Context(), config.DB, "password", input.Password, input.Email, 0)
+	if err != nil {
+		logger.Log.Error("Failed to validate password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+	if len(violations) > 0 {
+		logger.Log.Info("Registration password rejected by policy", zap.String("email", input.Email))
+		respondPasswordViolations(c, violations)
+		return
+	}
+
 	// パスワードのハッシュ化
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(input.Password)
 	if err != nil {
 		logger.Log.Error("Failed to hash password", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "パスワードのハッシュ化に失敗しました"})
@@ -48,18 +62,28 @@ func Register(c *gin.Context) {
 
 	user := models.User{
 		Email:    input.Email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 
-	// ユーザーの作成
-	if err := config.DB.Create(&user).Error; err != nil {
+	// ユーザーの作成と既定ロールの付与
+	if err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return assignDefaultRole(tx, &user)
+	}); err != nil {
 		logger.Log.Error("Failed to create user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザーの作成に失敗しました"})
 		return
 	}
 
+	// 確認メールの送信（失敗しても再送できるため登録は完了とする）
+	if err := sendVerificationEmail(c.Request.Context(), &user); err != nil {
+		logger.Log.Error("Failed to send verification email", zap.Error(err))
+	}
+
 	logger.Log.Info("User registered successfully", zap.String("email", user.Email))
-	c.JSON(http.StatusOK, gin.H{"message": "ユーザー登録が完了しました"})
+	c.JSON(http.StatusOK, gin.H{"message": "ユーザー登録が完了しました。確認メールをご確認ください"})
 }
 
 func Login(c *gin.Context) {
@@ -74,24 +98,106 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	// ロック中のアカウント・IPからの試行を拒否
+	if rejectLockedLogin(c, input.Email) {
+		return
+	}
+
 	var user models.User
 
-	// ユーザーの検索
+	// ユーザーの検索（存在しない場合もパスワードの照合と同じ時間をかけて応答する）
 	if err := config.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+		utils.VerifyDummyPassword(input.Password)
 		logger.Log.Info("Login attempt with non-existent email", zap.String("email", input.Email))
+		recordLoginFailure(c, input.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "メールアドレスまたはパスワードが間違っています"})
 		return
 	}
 
 	// パスワードの検証
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+	matched, needsRehash, err := utils.VerifyPassword(user.Password, input.Password)
+	if err != nil && !errors.Is(err, utils.ErrUnknownPasswordHash) {
+		logger.Log.Error("Failed to verify password", zap.Uint("userID", user.ID), zap.Error(err))
+	}
+	if !matched {
 		logger.Log.Info("Login attempt with incorrect password", zap.String("email", input.Email))
+		recordLoginFailure(c, input.Email)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "メールアドレスまたはパスワードが間違っています"})
 		return
 	}
 
-	// トークンの生成
-	token, err := utils.GenerateToken(user.ID)
+	// 古いアルゴリズムや弱いパラメータのハッシュを現在の設定で作り直す
+	if needsRehash {
+		rehashPassword(&user, input.Password)
+	}
+
+	// 無効化されたアカウントを拒否
+	if user.IsDisabled() {
+		logger.Log.Info("Login attempt for disabled account", zap.String("email", input.Email))
+		c.JSON(http.StatusForbidden, gin.H{"error": "このアカウントは無効化されています", "code": "account_disabled"})
+		return
+	}
+
+	// 管理者がパスワードの再設定を要求している
+	if user.PasswordResetRequired {
+		logger.Log.Info("Login attempt while password reset is required", zap.String("email", input.Email))
+		c.JSON(http.StatusForbidden, gin.H{"error": "パスワードの再設定が必要です。メールをご確認ください", "code": "password_reset_required"})
+		return
+	}
+
+	// メールアドレス未確認のアカウントを拒否
+	if config.Auth.RequireEmailVerification && user.VerifiedAt == nil {
+		logger.Log.Info("Login attempt with unverified email", zap.String("email", input.Email))
+		c.JSON(http.StatusForbidden, gin.H{"error": "メールアドレスが確認されていません", "code": "email_not_verified"})
+		return
+	}
+
+	// 二段階認証が有効な場合は確認待ちトークンを返す
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := utils.GenerateMFAPendingToken(user.ID)
+		if err != nil {
+			logger.Log.Error("Failed to generate MFA pending token", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの生成に失敗しました"})
+			return
+		}
+
+		logger.Log.Info("Password verified, awaiting second factor", zap.String("email", user.Email))
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": mfaToken})
+		return
+	}
+
+	respondWithTokens(c, &user)
+}
+
+// rehashPassword はログインに成功したパスワードを現在のアルゴリズムでハッシュ化し直す。
+// 同時にパスワードが変更されていた場合は上書きしない。失敗してもログインは続ける。
+func rehashPassword(user *models.User, password string) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		logger.Log.Error("Failed to rehash password", zap.Uint("userID", user.ID), zap.Error(err))
+		return
+	}
+
+	result := config.DB.Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		Update("password", hashedPassword)
+	if result.Error != nil {
+		logger.Log.Error("Failed to save rehashed password", zap.Uint("userID", user.ID), zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected == 1 {
+		user.Password = hashedPassword
+		logger.Log.Info("Password hash upgraded", zap.Uint("userID", user.ID))
+	}
+}
+
+// respondWithTokens はアクセストークンとリフレッシュトークンを発行してログイン成功のレスポンスを返す
+func respondWithTokens(c *gin.Context, user *models.User) {
+	if err := lockout.Default.Succeed(c.Request.Context(), user.Email); err != nil {
+		logger.Log.Error("Failed to reset login failures", zap.Error(err))
+	}
+
+	tokens, err := issueTokenPair(config.DB, user.ID)
 	if err != nil {
 		logger.Log.Error("Failed to generate token", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの生成に失敗しました"})
@@ -99,7 +205,14 @@ func Login(c *gin.Context) {
 	}
 
 	logger.Log.Info("User logged in successfully", zap.String("email", user.Email))
-	c.JSON(http.StatusOK, gin.H{"token": token, "id": user.ID, "email": user.Email})
+	setRefreshTokenCookie(c, tokens.RefreshToken)
+	c.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(config.Auth.AccessTokenTTL.Seconds()),
+		"id":            user.ID,
+		"email":         user.Email,
+	})
 }
 
 func GetUser(c *gin.Context) {
@@ -118,9 +231,18 @@ func GetUser(c *gin.Context) {
 		return
 	}
 
+	roles, permissions, err := loadAuthorities(config.DB, user.ID)
+	if err != nil {
+		logger.Log.Error("Failed to load user roles", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
 	logger.Log.Debug("User retrieved", zap.Uint("userID", user.ID))
 	c.JSON(http.StatusOK, gin.H{"user": gin.H{
-		"id":    user.ID,
-		"email": user.Email,
+		"id":          user.ID,
+		"email":       user.Email,
+		"roles":       roles,
+		"permissions": permissions,
 	}})
 }
@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"main/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS は検証用の公開鍵一覧を返す
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": utils.Keys.JWKS()})
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"main/lockout"
+	"main/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// rejectLockedLogin はロック中であれば 429 と Retry-After を返して true を返す
+func rejectLockedLogin(c *gin.Context, email string) bool {
+	wait, err := lockout.Default.Check(c.Request.Context(), email, c.ClientIP())
+	if err != nil {
+		logger.Log.Error("Failed to check login lockout", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return true
+	}
+	if wait <= 0 {
+		return false
+	}
+
+	logger.Log.Warn("Login attempt while locked out",
+		zap.String("email", email), zap.String("ip", c.ClientIP()), zap.Duration("retryAfter", wait))
+	c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "ログイン試行回数が多すぎます。しばらく時間をおいてから再度お試しください"})
+	return true
+}
+
+// recordLoginFailure はアカウントと IP のログイン失敗を記録する
+func recordLoginFailure(c *gin.Context, email string) {
+	if err := lockout.Default.Fail(c.Request.Context(), email, c.ClientIP()); err != nil {
+		logger.Log.Error("Failed to record login failure", zap.Error(err))
+	}
+}
+
+// UnlockLogin は管理者がアカウントまたは IP のロックを解除する
+func UnlockLogin(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"omitempty,email"`
+		IP    string `json:"ip" binding:"omitempty,ip"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Email == "" && input.IP == "") {
+		logger.Log.Warn("Invalid input for unlock", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email または ip を指定してください"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if input.Email != "" {
+		if err := lockout.Default.UnlockAccount(ctx, input.Email); err != nil {
+			logger.Log.Error("Failed to unlock account", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+			return
+		}
+	}
+	if input.IP != "" {
+		if err := lockout.Default.UnlockIP(ctx, input.IP); err != nil {
+			logger.Log.Error("Failed to unlock IP", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+			return
+		}
+	}
+
+	adminID, _ := c.Get("userID")
+	logger.Log.Info("Login lockout cleared",
+		zap.Any("adminID", adminID), zap.String("email", input.Email), zap.String("ip", input.IP))
+	c.JSON(http.StatusOK, gin.H{"message": "ロックを解除しました"})
+}
@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"main/config"
+	"main/logger"
+	"main/models"
+	"main/utils"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+// currentUser はコンテキストのユーザーIDからユーザーを読み込む。失敗時はレスポンスを書き込んで false を返す。
+func currentUser(c *gin.Context) (*models.User, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		logger.Log.Warn("User ID not found in context")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザーIDが見つかりません"})
+		return nil, false
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		logger.Log.Warn("User not found", zap.Any("userID", userID))
+		c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// verifySecondFactor は TOTP コードまたはリカバリーコードを検証する。
+// どちらも一度使ったものは受け付けない。
+func verifySecondFactor(db *gorm.DB, user *models.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok || step <= user.TOTPLastStep {
+			return false, nil
+		}
+
+		result := db.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if result.Error != nil {
+			return false, result.Error
+		}
+		return result.RowsAffected == 1, nil
+	}
+
+	if recoveryCode != "" {
+		recoveryCode = strings.ToLower(strings.TrimSpace(recoveryCode))
+
+		var codes []models.RecoveryCode
+		if err := db.Where("user_id = ? AND used_at IS NULL", user.ID).Find(&codes).Error; err != nil {
+			return false, err
+		}
+
+		for _, stored := range codes {
+			if bcrypt.CompareHashAndPassword([]byte(stored.CodeHash), []byte(recoveryCode)) != nil {
+				continue
+			}
+
+			result := db.Model(&models.RecoveryCode{}).
+				Where("id = ? AND used_at IS NULL", stored.ID).
+				Update("used_at", time.Now())
+			if result.Error != nil {
+				return false, result.Error
+			}
+			return result.RowsAffected == 1, nil
+		}
+	}
+
+	return false, nil
+}
+
+func EnrollTOTP(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "二段階認証は既に有効です"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		logger.Log.Error("Failed to generate TOTP secret", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	if err := config.DB.Model(user).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		logger.Log.Error("Failed to save TOTP secret", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	uri := utils.TOTPURI(config.Auth.TOTPIssuer, user.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		logger.Log.Error("Failed to generate QR code", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("TOTP enrollment started", zap.Uint("userID", user.ID))
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": uri,
+		"qr_code":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	})
+}
+
+func ConfirmTOTP(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for TOTP confirmation", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabledAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "二段階認証は既に有効です"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "二段階認証の登録が開始されていません"})
+		return
+	}
+
+	step, valid := utils.ValidateTOTP(user.TOTPSecret, input.Code, time.Now())
+	if !valid {
+		logger.Log.Info("Invalid TOTP code during confirmation", zap.Uint("userID", user.ID))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "認証コードが正しくありません"})
+		return
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		logger.Log.Error("Failed to generate recovery codes", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	records := make([]models.RecoveryCode, len(codes))
+	for i, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			logger.Log.Error("Failed to hash recovery code", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+			return
+		}
+		records[i] = models.RecoveryCode{UserID: user.ID, CodeHash: string(hash)}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  step,
+		}).Error
+	})
+	if err != nil {
+		logger.Log.Error("Failed to enable TOTP", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("TOTP enabled", zap.Uint("userID", user.ID))
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "二段階認証を有効にしました",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP は現在のパスワードと認証コードを確認して二段階認証を無効にする。
+// 盗まれたセッションからの総当たりを防ぐため、ログインと同じ試行回数の制限を適用する。
+func DisableTOTP(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		Code            string `json:"code"`
+		RecoveryCode    string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for disabling TOTP", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.TOTPEnabledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "二段階認証は有効になっていません"})
+		return
+	}
+
+	if !checkCurrentPassword(c, user, input.CurrentPassword) {
+		return
+	}
+
+	verified, err := verifySecondFactor(config.DB, user, input.Code, input.RecoveryCode)
+	if err != nil {
+		logger.Log.Error("Failed to verify second factor", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+	if !verified {
+		logger.Log.Info("Invalid code while disabling TOTP", zap.Uint("userID", user.ID))
+		recordLoginFailure(c, user.Email)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "認証コードが正しくありません"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+	})
+	if err != nil {
+		logger.Log.Error("Failed to disable TOTP", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("TOTP disabled", zap.Uint("userID", user.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "二段階認証を無効にしました"})
+}
+
+func LoginMFA(c *gin.Context) {
+	var input struct {
+		MFAToken     string `json:"mfa_token" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for MFA login", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, err := utils.ParseMFAPendingToken(input.MFAToken)
+	if err != nil {
+		logger.Log.Info("Invalid MFA pending token", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証の有効期限が切れています。もう一度ログインしてください"})
+		return
+	}
+	userID, _ := claims.UserID()
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil || user.TOTPEnabledAt == nil {
+		logger.Log.Warn("MFA login for unknown user or user without TOTP", zap.Uint("userID", userID))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証コードが正しくありません"})
+		return
+	}
+	if user.IsDisabled() {
+		logger.Log.Info("MFA login for disabled account", zap.Uint("userID", user.ID))
+		c.JSON(http.StatusForbidden, gin.H{"error": "このアカウントは無効化されています", "code": "account_disabled"})
+		return
+	}
+
+	if rejectLockedLogin(c, user.Email) {
+		return
+	}
+
+	verified, err := verifySecondFactor(config.DB, &user, input.Code, input.RecoveryCode)
+	if err != nil {
+		logger.Log.Error("Failed to verify second factor", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+	if !verified {
+		logger.Log.Info("Login attempt with incorrect MFA code", zap.Uint("userID", user.ID))
+		recordLoginFailure(c, user.Email)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "認証コードが正しくありません"})
+		return
+	}
+
+	respondWithTokens(c, &user)
+}
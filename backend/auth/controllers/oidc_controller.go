@@ -0,0 +1,277 @@
+package controllers
+
+import (
+	"errors"
+	"main/config"
+	"main/lockout"
+	"main/logger"
+	"main/models"
+	"main/oidc"
+	"main/utils"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const oidcStateCookie = "oidc_state"
+
+var (
+	errOIDCEmailUnverified        = errors.New("oidc email is not verified")
+	errOIDCLocalAccountUnverified = errors.New("local account email is not verified")
+	errOIDCAccountDeleted         = errors.New("account has been deleted")
+)
+
+// oidcProvider はパスパラメータのプロバイダを取得する。失敗時はレスポンスを書き込んで false を返す。
+func oidcProvider(c *gin.Context) (*oidc.Provider, bool) {
+	name := c.Param("provider")
+	provider, err := oidc.Default.Provider(c.Request.Context(), name)
+	if errors.Is(err, oidc.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "対応していないログイン方法です"})
+		return nil, false
+	}
+	if err != nil {
+		logger.Log.Error("Failed to load OIDC provider", zap.String("provider", name), zap.Error(err))
+		c.JSON(http.StatusBadGateway, gin.H{"error": "外部認証サービスに接続できません"})
+		return nil, false
+	}
+	return provider, true
+}
+
+// redirectOIDCFailure はフロントエンドのログイン画面にエラーを付けてリダイレクトする
+func redirectOIDCFailure(c *gin.Context) {
+	redirectOIDCError(c, "oidc_failed")
+}
+
+// redirectOIDCError はフロントエンドのログイン画面に理由を示すエラーコードを付けてリダイレクトする
+func redirectOIDCError(c *gin.Context, code string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", config.Auth.CookieSecure, true)
+	c.Redirect(http.StatusFound, config.Auth.AppBaseURL+"/login?error="+url.QueryEscape(code))
+}
+
+func OIDCLogin(c *gin.Context) {
+	provider, ok := oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	state, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log.Error("Failed to generate OIDC state", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+	nonce, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		logger.Log.Error("Failed to generate OIDC nonce", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+	codeVerifier := oauth2.GenerateVerifier()
+
+	// 認可画面で中断された認可リクエストは consume されないため、開始のたびに期限切れを削除する
+	purgeExpiredOIDCAuthRequests(config.DB)
+
+	request := models.OIDCAuthRequest{
+		State:        state,
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(config.Auth.OIDCAuthRequestTTL),
+	}
+	if err := config.DB.Create(&request).Error; err != nil {
+		logger.Log.Error("Failed to save OIDC auth request", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	// state をブラウザにも結び付け、別のブラウザで開始された認可応答を受け付けないようにする
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(config.Auth.OIDCAuthRequestTTL.Seconds()), "/auth/oidc", "", config.Auth.CookieSecure, true)
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state, nonce, codeVerifier))
+}
+
+func OIDCCallback(c *gin.Context) {
+	provider, ok := oidcProvider(c)
+	if !ok {
+		return
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		logger.Log.Info("OIDC provider returned an error",
+			zap.String("provider", provider.Name), zap.String("error", errCode), zap.String("description", c.Query("error_description")))
+		redirectOIDCFailure(c)
+		return
+	}
+
+	state := c.Query("state")
+	cookieState, err := c.Cookie(oidcStateCookie)
+	if state == "" || err != nil || cookieState != state {
+		logger.Log.Warn("OIDC state mismatch", zap.String("provider", provider.Name))
+		redirectOIDCFailure(c)
+		return
+	}
+
+	request, err := consumeOIDCAuthRequest(config.DB, provider.Name, state)
+	if err != nil {
+		logger.Log.Warn("Invalid OIDC auth request", zap.String("provider", provider.Name), zap.Error(err))
+		redirectOIDCFailure(c)
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), request.CodeVerifier, request.Nonce)
+	if err != nil {
+		logger.Log.Warn("OIDC token exchange failed", zap.String("provider", provider.Name), zap.Error(err))
+		redirectOIDCFailure(c)
+		return
+	}
+
+	user, err := findOrProvisionOIDCUser(config.DB, provider.Name, claims)
+	if errors.Is(err, errOIDCAccountDeleted) {
+		logger.Log.Info("OIDC login for deleted account", zap.String("provider", provider.Name), zap.String("email", claims.Email))
+		redirectOIDCError(c, "account_deleted")
+		return
+	}
+	if err != nil {
+		logger.Log.Warn("Failed to link OIDC identity", zap.String("provider", provider.Name), zap.Error(err))
+		redirectOIDCFailure(c)
+		return
+	}
+
+	if user.IsDisabled() {
+		logger.Log.Info("OIDC login for disabled account", zap.Uint("userID", user.ID))
+		redirectOIDCFailure(c)
+		return
+	}
+
+	// 二段階認証が有効な場合は確認待ちトークンをフラグメントで渡す
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := utils.GenerateMFAPendingToken(user.ID)
+		if err != nil {
+			logger.Log.Error("Failed to generate MFA pending token", zap.Error(err))
+			redirectOIDCFailure(c)
+			return
+		}
+
+		logger.Log.Info("OIDC login verified, awaiting second factor", zap.Uint("userID", user.ID))
+		c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", config.Auth.CookieSecure, true)
+		c.Redirect(http.StatusFound, config.Auth.OIDCPostLoginRedirect+"#mfa_token="+url.QueryEscape(mfaToken))
+		return
+	}
+
+	if err := lockout.Default.Succeed(c.Request.Context(), user.Email); err != nil {
+		logger.Log.Error("Failed to reset login failures", zap.Error(err))
+	}
+
+	tokens, err := issueTokenPair(config.DB, user.ID)
+	if err != nil {
+		logger.Log.Error("Failed to generate token", zap.Error(err))
+		redirectOIDCFailure(c)
+		return
+	}
+
+	// アクセストークンはフロントエンドがリフレッシュトークンのクッキーで /auth/refresh から取得する
+	logger.Log.Info("User logged in via OIDC", zap.String("provider", provider.Name), zap.Uint("userID", user.ID))
+	setRefreshTokenCookie(c, tokens.RefreshToken)
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", config.Auth.CookieSecure, true)
+	c.Redirect(http.StatusFound, config.Auth.OIDCPostLoginRedirect)
+}
+
+// consumeOIDCAuthRequest は state に対応する認可リクエストを取り出して削除する。
+// 削除できた場合のみ有効とするため、同じ state は一度しか使えない。
+func consumeOIDCAuthRequest(db *gorm.DB, provider, state string) (*models.OIDCAuthRequest, error) {
+	var request models.OIDCAuthRequest
+	if err := db.Where("state = ? AND provider = ?", state, provider).First(&request).Error; err != nil {
+		return nil, err
+	}
+
+	result := db.Where("id = ?", request.ID).Delete(&models.OIDCAuthRequest{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if time.Now().After(request.ExpiresAt) {
+		return nil, errors.New("oidc auth request expired")
+	}
+	return &request, nil
+}
+
+// purgeExpiredOIDCAuthRequests は期限切れの認可リクエストを削除する。失敗してもログインは続ける。
+func purgeExpiredOIDCAuthRequests(db *gorm.DB) {
+	result := db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCAuthRequest{})
+	if result.Error != nil {
+		logger.Log.Error("Failed to purge expired OIDC auth requests", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Info("Purged expired OIDC auth requests", zap.Int64("count", result.RowsAffected))
+	}
+}
+
+// findOrProvisionOIDCUser は (provider, sub) に紐付いたユーザーを返す。
+// 紐付けがない場合、IdP が確認済みのメールアドレスを持つ既存ユーザーに紐付けるか、新規ユーザーを作成する。
+// 削除済みのユーザーはメールアドレスを保持したままのため、Unscoped で検索して errOIDCAccountDeleted を返す。
+func findOrProvisionOIDCUser(db *gorm.DB, provider string, claims *oidc.Claims) (*models.User, error) {
+	var user models.User
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+		if err == nil {
+			if err := tx.Unscoped().First(&user, identity.UserID).Error; err != nil {
+				return err
+			}
+			if user.DeletedAt.Valid {
+				return errOIDCAccountDeleted
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// 未確認のメールアドレスで既存アカウントを乗っ取られないよう、確認済みの場合のみ照合する
+		if !claims.EmailVerified || claims.Email == "" {
+			return errOIDCEmailUnverified
+		}
+
+		err = tx.Unscoped().Where("email = ?", claims.Email).First(&user).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			now := time.Now()
+			user = models.User{Email: claims.Email, VerifiedAt: &now}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			if err := assignDefaultRole(tx, &user); err != nil {
+				return err
+			}
+			logger.Log.Info("User provisioned via OIDC", zap.String("provider", provider), zap.String("email", user.Email))
+		case err != nil:
+			return err
+		case user.DeletedAt.Valid:
+			return errOIDCAccountDeleted
+		case user.VerifiedAt == nil:
+			// 第三者が先にパスワードで登録したアカウントの可能性があるため紐付けない
+			return errOIDCLocalAccountUnverified
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,353 @@
+package controllers
+
+import (
+	"main/config"
+	"main/models"
+	"main/oidc"
+	"main/oidc/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type oidcTestEnv struct {
+	db     *gorm.DB
+	router *gin.Engine
+	issuer *oidctest.Issuer
+}
+
+func setupOIDCTest(t *testing.T) *oidcTestEnv {
+	t.Helper()
+
+	db := setupTestEnv(t)
+	issuer := oidctest.NewIssuer(t, "client-id")
+	oidc.Default = oidc.NewRegistry([]config.OIDCProviderConfig{{
+		Name:         "test",
+		Issuer:       issuer.URL(),
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/auth/oidc/test/callback",
+		Scopes:       []string{"openid", "email"},
+	}})
+
+	router := gin.New()
+	router.GET("/auth/oidc/:provider/login", OIDCLogin)
+	router.GET("/auth/oidc/:provider/callback", OIDCCallback)
+	return &oidcTestEnv{db: db, router: router, issuer: issuer}
+}
+
+// start はログインを開始し、IdP の認可エンドポイントの URL と state のクッキーを返す
+func (e *oidcTestEnv) start(t *testing.T) (string, *http.Cookie) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/test/login", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d, want %d: %s", w.Code, http.StatusFound, w.Body.String())
+	}
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == oidcStateCookie {
+			return w.Header().Get("Location"), cookie
+		}
+	}
+	t.Fatal("login did not set the state cookie")
+	return "", nil
+}
+
+// callback は IdP からのリダイレクトを再現し、リダイレクト先を返す
+func (e *oidcTestEnv) callback(t *testing.T, code, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	query := url.Values{"code": {code}, "state": {state}}
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/test/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("callback status = %d, want %d: %s", w.Code, http.StatusFound, w.Body.String())
+	}
+	return w
+}
+
+// login は IdP のユーザーとしてログインを最後まで行う
+func (e *oidcTestEnv) login(t *testing.T, identity oidctest.Identity) *httptest.ResponseRecorder {
+	t.Helper()
+
+	authURL, cookie := e.start(t)
+	code, err := e.issuer.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return e.callback(t, code, stateOf(t, authURL), cookie)
+}
+
+func stateOf(t *testing.T, authURL string) string {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid auth URL %q: %v", authURL, err)
+	}
+	return u.Query().Get("state")
+}
+
+func assertOIDCSucceeded(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	if got := w.Header().Get("Location"); got != config.Auth.OIDCPostLoginRedirect {
+		t.Fatalf("redirect = %q, want %q", got, config.Auth.OIDCPostLoginRedirect)
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == refreshTokenCookie && cookie.Value != "" {
+			return
+		}
+	}
+	t.Error("successful login did not set the refresh token cookie")
+}
+
+func assertOIDCFailed(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	if got, want := w.Header().Get("Location"), config.Auth.AppBaseURL+"/login?error=oidc_failed"; got != want {
+		t.Fatalf("redirect = %q, want %q", got, want)
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == refreshTokenCookie {
+			t.Error("failed login set the refresh token cookie")
+		}
+	}
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	env := setupOIDCTest(t)
+	identity := oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true}
+
+	assertOIDCSucceeded(t, env.login(t, identity))
+
+	var user models.User
+	if err := env.db.Preload("Roles").Where("email = ?", identity.Email).First(&user).Error; err != nil {
+		t.Fatalf("user was not provisioned: %v", err)
+	}
+	if user.VerifiedAt == nil {
+		t.Error("provisioned user should be verified")
+	}
+	if len(user.Roles) != 1 || user.Roles[0].Name != config.Auth.DefaultRole {
+		t.Errorf("roles = %+v, want the default role", user.Roles)
+	}
+
+	// 同じ sub での 2 回目のログインは、IdP 側でメールアドレスが変わっても同じユーザーになる
+	identity.Email = "renamed@example.com"
+	assertOIDCSucceeded(t, env.login(t, identity))
+	if n := countRows(t, env.db, &models.User{}); n != 1 {
+		t.Errorf("users = %d, want 1", n)
+	}
+	if n := countRows(t, env.db, &models.UserIdentity{}); n != 1 {
+		t.Errorf("identities = %d, want 1", n)
+	}
+}
+
+func TestOIDCLoginLinksVerifiedAccount(t *testing.T) {
+	env := setupOIDCTest(t)
+	now := time.Now()
+	existing := models.User{Email: "local@example.com", VerifiedAt: &now}
+	if err := env.db.Create(&existing).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	assertOIDCSucceeded(t, env.login(t, oidctest.Identity{Subject: "sub-1", Email: existing.Email, EmailVerified: true}))
+
+	var identity models.UserIdentity
+	if err := env.db.Where("provider = ? AND subject = ?", "test", "sub-1").First(&identity).Error; err != nil {
+		t.Fatalf("identity was not linked: %v", err)
+	}
+	if identity.UserID != existing.ID {
+		t.Errorf("identity linked to user %d, want %d", identity.UserID, existing.ID)
+	}
+	if n := countRows(t, env.db, &models.User{}); n != 1 {
+		t.Errorf("users = %d, want 1", n)
+	}
+}
+
+func TestOIDCLoginRefusesUnverifiedLocalAccount(t *testing.T) {
+	env := setupOIDCTest(t)
+	existing := models.User{Email: "local@example.com"}
+	if err := env.db.Create(&existing).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	assertOIDCFailed(t, env.login(t, oidctest.Identity{Subject: "sub-1", Email: existing.Email, EmailVerified: true}))
+
+	if n := countRows(t, env.db, &models.UserIdentity{}); n != 0 {
+		t.Errorf("identities = %d, want 0", n)
+	}
+}
+
+func TestOIDCLoginRejectsUnverifiedEmail(t *testing.T) {
+	env := setupOIDCTest(t)
+
+	assertOIDCFailed(t, env.login(t, oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: false}))
+
+	if n := countRows(t, env.db, &models.User{}); n != 0 {
+		t.Errorf("users = %d, want 0", n)
+	}
+}
+
+func TestOIDCCallbackRejectsStateMismatch(t *testing.T) {
+	env := setupOIDCTest(t)
+	identity := oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name   string
+		cookie func(*http.Cookie) *http.Cookie
+	}{
+		{"missing cookie", func(*http.Cookie) *http.Cookie { return nil }},
+		{"different cookie", func(c *http.Cookie) *http.Cookie {
+			return &http.Cookie{Name: c.Name, Value: "other-state"}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, cookie := env.start(t)
+			code, err := env.issuer.Authorize(authURL, identity)
+			if err != nil {
+				t.Fatalf("Authorize: %v", err)
+			}
+
+			assertOIDCFailed(t, env.callback(t, code, stateOf(t, authURL), tt.cookie(cookie)))
+
+			// 不一致の場合は認可リクエストを消費しない
+			var n int64
+			env.db.Model(&models.OIDCAuthRequest{}).Where("state = ?", stateOf(t, authURL)).Count(&n)
+			if n != 1 {
+				t.Errorf("auth requests for the state = %d, want 1", n)
+			}
+		})
+	}
+
+	if n := countRows(t, env.db, &models.User{}); n != 0 {
+		t.Errorf("users = %d, want 0", n)
+	}
+}
+
+func TestOIDCCallbackRejectsReplayedState(t *testing.T) {
+	env := setupOIDCTest(t)
+	identity := oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true}
+
+	authURL, cookie := env.start(t)
+	code, err := env.issuer.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	assertOIDCSucceeded(t, env.callback(t, code, stateOf(t, authURL), cookie))
+
+	replayed, err := env.issuer.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	assertOIDCFailed(t, env.callback(t, replayed, stateOf(t, authURL), cookie))
+}
+
+func TestOIDCCallbackRejectsPKCEMismatch(t *testing.T) {
+	env := setupOIDCTest(t)
+
+	authURL, cookie := env.start(t)
+	grant, err := env.issuer.GrantFromURL(authURL, oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("GrantFromURL: %v", err)
+	}
+	// 別の認可リクエストで発行された認可コードを差し込まれた場合を再現する
+	grant.CodeChallenge = oidctest.CodeChallenge("attacker-verifier")
+
+	assertOIDCFailed(t, env.callback(t, env.issuer.IssueCode(grant), stateOf(t, authURL), cookie))
+
+	if n := countRows(t, env.db, &models.User{}); n != 0 {
+		t.Errorf("users = %d, want 0", n)
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	env := setupOIDCTest(t)
+
+	authURL, cookie := env.start(t)
+	grant, err := env.issuer.GrantFromURL(authURL, oidctest.Identity{Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("GrantFromURL: %v", err)
+	}
+	grant.Nonce = "other-nonce"
+
+	assertOIDCFailed(t, env.callback(t, env.issuer.IssueCode(grant), stateOf(t, authURL), cookie))
+
+	if n := countRows(t, env.db, &models.User{}); n != 0 {
+		t.Errorf("users = %d, want 0", n)
+	}
+}
+
+func TestOIDCLoginPurgesExpiredAuthRequests(t *testing.T) {
+	env := setupOIDCTest(t)
+	expired := models.OIDCAuthRequest{
+		State:        "expired-state",
+		Provider:     "test",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	}
+	if err := env.db.Create(&expired).Error; err != nil {
+		t.Fatalf("failed to create auth request: %v", err)
+	}
+
+	env.start(t)
+
+	var n int64
+	env.db.Model(&models.OIDCAuthRequest{}).Where("state = ?", expired.State).Count(&n)
+	if n != 0 {
+		t.Error("expired auth request was not purged")
+	}
+	if n := countRows(t, env.db, &models.OIDCAuthRequest{}); n != 1 {
+		t.Errorf("auth requests = %d, want 1 (the new one)", n)
+	}
+}
+
+func TestOIDCLoginRejectsDeletedAccount(t *testing.T) {
+	tests := []struct {
+		name   string
+		linked bool
+	}{
+		{"linked identity", true},
+		{"matching email", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := setupOIDCTest(t)
+			now := time.Now()
+			user := models.User{Email: "deleted@example.com", VerifiedAt: &now}
+			if err := env.db.Create(&user).Error; err != nil {
+				t.Fatalf("failed to create user: %v", err)
+			}
+			if tt.linked {
+				if err := env.db.Create(&models.UserIdentity{UserID: user.ID, Provider: "test", Subject: "sub-1"}).Error; err != nil {
+					t.Fatalf("failed to create identity: %v", err)
+				}
+			}
+			if err := env.db.Delete(&user).Error; err != nil {
+				t.Fatalf("failed to delete user: %v", err)
+			}
+
+			w := env.login(t, oidctest.Identity{Subject: "sub-1", Email: user.Email, EmailVerified: true})
+
+			if got, want := w.Header().Get("Location"), config.Auth.AppBaseURL+"/login?error=account_deleted"; got != want {
+				t.Errorf("redirect = %q, want %q", got, want)
+			}
+			if n := countRows(t, env.db, &models.User{}); n != 1 {
+				t.Errorf("users = %d, want 1", n)
+			}
+		})
+	}
+}
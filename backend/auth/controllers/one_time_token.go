@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"errors"
+	"main/models"
+	"main/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var errInvalidOneTimeToken = errors.New("invalid one-time token")
+
+// issueOneTimeToken は用途ごとの使い捨てトークンを発行して平文を返す。
+// 同じ用途の未使用トークンは無効化する。
+func issueOneTimeToken(db *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OneTimeToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.OneTimeToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consumeOneTimeToken はトークンを検証して使用済みにする。
+// 同時に使われた場合でも成功するのは一度だけ。
+func consumeOneTimeToken(tx *gorm.DB, rawToken, purpose string) (*models.OneTimeToken, error) {
+	var token models.OneTimeToken
+	if err := tx.Where("token_hash = ? AND purpose = ?", utils.HashToken(rawToken), purpose).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errInvalidOneTimeToken
+		}
+		return nil, err
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, errInvalidOneTimeToken
+	}
+
+	result := tx.Model(&models.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errInvalidOneTimeToken
+	}
+	return &token, nil
+}
+
+// lastOneTimeTokenIssuedAt は直近に発行したトークンの発行時刻を返す
+func lastOneTimeTokenIssuedAt(db *gorm.DB, userID uint, purpose string) (time.Time, bool, error) {
+	var token models.OneTimeToken
+	err := db.Where("user_id = ? AND purpose = ?", userID, purpose).Order("created_at DESC").First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return token.CreatedAt, true, nil
+}
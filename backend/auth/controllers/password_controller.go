@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/config"
+	"main/logger"
+	"main/mail"
+	"main/models"
+	"main/utils"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// passwordResetMailTimeout は応答後に行う再設定メールの送信の制限時間
+const passwordResetMailTimeout = time.Minute
+
+// sendPasswordResetEmail はリセット用トークンを発行してメールで送る
+func sendPasswordResetEmail(ctx context.Context, user *models.User) error {
+	token, err := issueOneTimeToken(config.DB, user.ID, models.TokenPurposePasswordReset, config.Auth.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.Auth.AppBaseURL, url.QueryEscape(token))
+	return mail.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "パスワードの再設定",
+		Body:    fmt.Sprintf("以下のリンクからパスワードを再設定してください。\n\n%s\n\nこのリンクの有効期限は%sです。心当たりがない場合はこのメールを破棄してください。\n", link, config.Auth.PasswordResetTTL),
+	})
+}
+
+func ForgotPassword(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for forgot password", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// メールアドレスの登録有無で応答の内容・時間が変わらないよう、検索と送信は応答後に行う。
+	// リクエストの終了で取り消されないよう、キャンセルを引き継がないコンテキストを使う。
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), passwordResetMailTimeout)
+	go func() {
+		defer cancel()
+		requestPasswordReset(ctx, input.Email)
+	}()
+
+	c.JSON(http.StatusOK, gin.H{"message": "パスワード再設定用のメールを送信しました"})
+}
+
+// requestPasswordReset は登録済みのメールアドレスであれば再設定用のメールを送る。
+// 結果は応答に含めず、ログにだけ残す。
+func requestPasswordReset(ctx context.Context, email string) {
+	var user models.User
+	if err := config.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Log.Error("Database error during forgot password", zap.Error(err))
+		} else {
+			logger.Log.Info("Password reset requested for non-existent email", zap.String("email", email))
+		}
+		return
+	}
+
+	// 短時間に連続で送らない
+	lastIssuedAt, found, err := lastOneTimeTokenIssuedAt(config.DB.WithContext(ctx), user.ID, models.TokenPurposePasswordReset)
+	if err != nil {
+		logger.Log.Error("Database error during forgot password", zap.Error(err))
+		return
+	}
+	if found && time.Since(lastIssuedAt) < config.Auth.EmailResendInterval {
+		logger.Log.Info("Password reset email throttled", zap.Uint("userID", user.ID))
+		return
+	}
+
+	if err := sendPasswordResetEmail(ctx, &user); err != nil {
+		logger.Log.Error("Failed to send password reset email", zap.Error(err))
+		return
+	}
+
+	logger.Log.Info("Password reset email sent", zap.Uint("userID", user.ID))
+}
+
+func ResetPassword(c *gin.Context) {
+	var input struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for password reset", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// ハッシュ化は負荷が高いため、トークンとパスワードポリシーの検証を通ってから行う
+	var userID uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeOneTimeToken(tx, input.Token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		userID = token.UserID
+
+		var user models.User
+		if err := tx.Select("id", "email").First(&user, token.UserID).Error; err != nil {
+			return err
+		}
+
+		// 違反があればロールバックし、トークンを使用済みにしない
+		violations, err := validateNewPassword(c.Request.Context(), tx, "password", input.Password, user.Email, user.ID)
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			return &passwordViolationError{violations: violations}
+		}
+
+		hashedPassword, err := utils.HashPassword(input.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %v", err)
+		}
+		if err := updatePassword(tx, token.UserID, hashedPassword); err != nil {
+			return err
+		}
+
+		// 既存のセッションとリフレッシュトークンを全て無効化
+		return revokeAllSessions(tx, token.UserID)
+	})
+	var violation *passwordViolationError
+	if errors.As(err, &violation) {
+		logger.Log.Info("Reset password rejected by policy", zap.Uint("userID", userID))
+		respondPasswordViolations(c, violation.violations)
+		return
+	}
+	if errors.Is(err, errInvalidOneTimeToken) {
+		logger.Log.Info("Invalid or expired password reset token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "リセット用リンクが無効か、有効期限が切れています"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to reset password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("Password reset", zap.Uint("userID", userID))
+	c.JSON(http.StatusOK, gin.H{"message": "パスワードを再設定しました"})
+}
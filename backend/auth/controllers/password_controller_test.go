@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"main/config"
+	"main/models"
+	"main/passwordpolicy"
+	"main/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func resetPassword(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/reset-password", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func setupResetPasswordTest(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	setupTestEnv(t)
+	if err := passwordpolicy.Init(); err != nil {
+		t.Fatalf("failed to initialize password policy: %v", err)
+	}
+	if err := utils.InitPasswordHashers("bcrypt", utils.Argon2idHasher{}, 4); err != nil {
+		t.Fatalf("failed to initialize password hashers: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/auth/reset-password", ResetPassword)
+	return router
+}
+
+func TestResetPasswordDoesNotHashForInvalidToken(t *testing.T) {
+	router := setupResetPasswordTest(t)
+
+	// 無効なトークンでハッシュ化が行われればパニックになる
+	hashers := utils.PasswordHashers
+	utils.PasswordHashers = nil
+	defer func() { utils.PasswordHashers = hashers }()
+
+	w := resetPassword(router, `{"token":"made-up","password":"new-password-123"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
+
+func TestResetPasswordUpdatesPassword(t *testing.T) {
+	router := setupResetPasswordTest(t)
+	user := models.User{Email: "user@example.com"}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	token, err := issueOneTimeToken(config.DB, user.ID, models.TokenPurposePasswordReset, config.Auth.PasswordResetTTL)
+	if err != nil {
+		t.Fatalf("issueOneTimeToken: %v", err)
+	}
+
+	body := `{"token":"` + token + `","password":"new-password-123"}`
+	if w := resetPassword(router, body); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if err := config.DB.First(&user, user.ID).Error; err != nil {
+		t.Fatalf("failed to reload user: %v", err)
+	}
+	if ok, _, _ := utils.VerifyPassword(user.Password, "new-password-123"); !ok {
+		t.Error("password was not updated")
+	}
+	if w := resetPassword(router, body); w.Code != http.StatusBadRequest {
+		t.Errorf("reused token status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"main/models"
+	"main/passwordpolicy"
+	"main/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// passwordViolationError はトランザクション内でポリシー違反を検出したことを呼び出し元に伝える
+type passwordViolationError struct {
+	violations []passwordpolicy.Violation
+}
+
+func (e *passwordViolationError) Error() string {
+	return "password policy violation"
+}
+
+// validateNewPassword はポリシーと過去のパスワードの再利用を検証する。
+// userID が 0 の場合（新規登録）は履歴を確認しない。
+func validateNewPassword(ctx context.Context, db *gorm.DB, field, password, email string, userID uint) ([]passwordpolicy.Violation, error) {
+	violations, err := passwordpolicy.Default.Validate(ctx, field, password, email)
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 || passwordpolicy.Default.HistorySize <= 0 {
+		return violations, nil
+	}
+
+	reused, err := passwordReused(db, userID, password)
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		violations = append(violations, passwordpolicy.Violation{
+			Field:   field,
+			Code:    "reused",
+			Message: "最近使用したパスワードは使用できません",
+		})
+	}
+	return violations, nil
+}
+
+// passwordReused は現在のパスワードと直近の履歴のいずれかと一致するか判定する
+func passwordReused(db *gorm.DB, userID uint, password string) (bool, error) {
+	var user models.User
+	if err := db.Select("id", "password").First(&user, userID).Error; err != nil {
+		return false, err
+	}
+	hashes := []string{user.Password}
+
+	var history []models.PasswordHistory
+	if err := db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(passwordpolicy.Default.HistorySize - 1).
+		Find(&history).Error; err != nil {
+		return false, err
+	}
+	for _, h := range history {
+		hashes = append(hashes, h.Hash)
+	}
+
+	for _, hash := range hashes {
+		if matched, _, _ := utils.VerifyPassword(hash, password); matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// updatePassword はパスワードを更新し、それまでのハッシュを履歴に残す
+func updatePassword(tx *gorm.DB, userID uint, hashedPassword string) error {
+	var user models.User
+	if err := tx.Select("id", "password").First(&user, userID).Error; err != nil {
+		return err
+	}
+
+	if user.Password != "" && passwordpolicy.Default.HistorySize > 1 {
+		if err := tx.Create(&models.PasswordHistory{UserID: userID, Hash: user.Password}).Error; err != nil {
+			return err
+		}
+
+		// 確認に使う件数を超えた古い履歴は削除する
+		if err := tx.Where("user_id = ? AND id NOT IN (?)", userID,
+			tx.Model(&models.PasswordHistory{}).
+				Select("id").
+				Where("user_id = ?", userID).
+				Order("created_at DESC").
+				Limit(passwordpolicy.Default.HistorySize-1),
+		).Delete(&models.PasswordHistory{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password":                hashedPassword,
+			"password_reset_required": false,
+		}).Error
+}
+
+// respondPasswordViolations はポリシー違反をフィールド単位のエラーとして返す
+func respondPasswordViolations(c *gin.Context, violations []passwordpolicy.Violation) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  "パスワードが要件を満たしていません",
+		"code":   "password_policy",
+		"fields": violations,
+	})
+}
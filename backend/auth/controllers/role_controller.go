@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"errors"
+	"main/config"
+	"main/logger"
+	"main/models"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// loadAuthorities はユーザーのロール名と、ロールから導かれる権限名を返す
+func loadAuthorities(db *gorm.DB, userID uint) ([]string, []string, error) {
+	var user models.User
+	if err := db.Preload("Roles.Permissions").First(&user, userID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	roles := make([]string, 0, len(user.Roles))
+	seen := make(map[string]bool)
+	var permissions []string
+	for _, role := range user.Roles {
+		roles = append(roles, role.Name)
+		for _, name := range role.PermissionNames() {
+			if !seen[name] {
+				seen[name] = true
+				permissions = append(permissions, name)
+			}
+		}
+	}
+	sort.Strings(roles)
+	sort.Strings(permissions)
+	return roles, permissions, nil
+}
+
+// assignDefaultRole は新規ユーザーに DEFAULT_ROLE を付与する
+func assignDefaultRole(db *gorm.DB, user *models.User) error {
+	if config.Auth.DefaultRole == "" {
+		return nil
+	}
+
+	var role models.Role
+	if err := db.Where("name = ?", config.Auth.DefaultRole).First(&role).Error; err != nil {
+		return err
+	}
+	return db.Model(user).Association("Roles").Append(&role)
+}
+
+// findPermissions は権限名から権限を検索する。存在しない名前があればエラーを返す。
+func findPermissions(db *gorm.DB, names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	if err := db.Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	if len(permissions) != len(names) {
+		return nil, errUnknownPermission
+	}
+	return permissions, nil
+}
+
+var (
+	errUnknownPermission = errors.New("unknown permission")
+	errProtectedRole     = errors.New("protected role")
+)
+
+func ListPermissions(c *gin.Context) {
+	var permissions []models.Permission
+	if err := config.DB.Order("name").Find(&permissions).Error; err != nil {
+		logger.Log.Error("Failed to list permissions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"permissions": permissions})
+}
+
+func ListRoles(c *gin.Context) {
+	var roles []models.Role
+	if err := config.DB.Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		logger.Log.Error("Failed to list roles", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+type roleInput struct {
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+func CreateRole(c *gin.Context) {
+	var input roleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for role creation", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := models.Role{Name: input.Name, Description: input.Description}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		permissions, err := findPermissions(tx, input.Permissions)
+		if err != nil {
+			return err
+		}
+		role.Permissions = permissions
+
+		// 論理削除で残った同名のロールがあれば一意インデックスと衝突するため先に消す
+		if err := tx.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", role.Name).
+			Delete(&models.Role{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&role).Error
+	})
+	if errors.Is(err, errUnknownPermission) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "存在しない権限が含まれています"})
+		return
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "同じ名前のロールが既に存在します"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to create role", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ロールの作成に失敗しました"})
+		return
+	}
+
+	logger.Log.Info("Role created", zap.String("role", role.Name))
+	c.JSON(http.StatusCreated, gin.H{"role": role})
+}
+
+func UpdateRole(c *gin.Context) {
+	var input roleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for role update", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&role, id).Error; err != nil {
+			return err
+		}
+		if input.Name != role.Name && config.IsProtectedRole(role.Name) {
+			return errProtectedRole
+		}
+
+		permissions, err := findPermissions(tx, input.Permissions)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&role).Updates(map[string]interface{}{
+			"name":        input.Name,
+			"description": input.Description,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+		role.Permissions = permissions
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ロールが見つかりません"})
+		return
+	}
+	if errors.Is(err, errUnknownPermission) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "存在しない権限が含まれています"})
+		return
+	}
+	if errors.Is(err, errProtectedRole) {
+		c.JSON(http.StatusConflict, gin.H{"error": "組み込みのロールと既定のロールは名前を変更できません"})
+		return
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "同じ名前のロールが既に存在します"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to update role", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ロールの更新に失敗しました"})
+		return
+	}
+
+	logger.Log.Info("Role updated", zap.String("role", role.Name))
+	c.JSON(http.StatusOK, gin.H{"role": role})
+}
+
+func DeleteRole(c *gin.Context) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&role, id).Error; err != nil {
+			return err
+		}
+		if config.IsProtectedRole(role.Name) {
+			return errProtectedRole
+		}
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", role.ID).Error; err != nil {
+			return err
+		}
+		// 名前の一意インデックスを空けるため物理削除する
+		return tx.Unscoped().Delete(&role).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ロールが見つかりません"})
+		return
+	}
+	if errors.Is(err, errProtectedRole) {
+		c.JSON(http.StatusConflict, gin.H{"error": "組み込みのロールと既定のロールは削除できません"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to delete role", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ロールの削除に失敗しました"})
+		return
+	}
+
+	logger.Log.Info("Role deleted", zap.String("role", role.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "ロールを削除しました"})
+}
+
+func AssignUserRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for role assignment", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := adminTargetUser(c, config.DB)
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	if err := config.DB.Where("name = ?", input.Role).First(&role).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ロールが見つかりません"})
+		return
+	}
+
+	if err := config.DB.Model(user).Association("Roles").Append(&role); err != nil {
+		logger.Log.Error("Failed to assign role", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ロールの付与に失敗しました"})
+		return
+	}
+
+	logger.Log.Info("Role assigned", zap.Uint("userID", user.ID), zap.String("role", role.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "ロールを付与しました"})
+}
+
+func RemoveUserRole(c *gin.Context) {
+	user, ok := adminTargetUser(c, config.DB)
+	if !ok {
+		return
+	}
+
+	var role models.Role
+	if err := config.DB.Where("name = ?", c.Param("role")).First(&role).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ロールが見つかりません"})
+		return
+	}
+
+	if err := config.DB.Model(user).Association("Roles").Delete(&role); err != nil {
+		logger.Log.Error("Failed to remove role", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ロールの削除に失敗しました"})
+		return
+	}
+
+	logger.Log.Info("Role removed", zap.Uint("userID", user.ID), zap.String("role", role.Name))
+	c.JSON(http.StatusOK, gin.H{"message": "ロールを外しました"})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"main/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRoleRoutesValidateID(t *testing.T) {
+	db := setupTestEnv(t)
+	role := models.Role{Name: "custom"}
+	if err := db.Create(&role).Error; err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+
+	router := gin.New()
+	router.PUT("/admin/roles/:id", UpdateRole)
+	router.DELETE("/admin/roles/:id", DeleteRole)
+	router.POST("/admin/users/:id/roles", AssignUserRole)
+	router.DELETE("/admin/users/:id/roles/:role", RemoveUserRole)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{http.MethodPut, "/admin/roles/1=1", `{"name":"renamed"}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/roles/999", `{"name":"renamed"}`, http.StatusNotFound},
+		{http.MethodDelete, "/admin/roles/id>0", "", http.StatusBadRequest},
+		{http.MethodDelete, "/admin/roles/abc", "", http.StatusBadRequest},
+		{http.MethodDelete, "/admin/roles/999", "", http.StatusNotFound},
+		{http.MethodPost, "/admin/users/1=1/roles", `{"role":"custom"}`, http.StatusBadRequest},
+		{http.MethodPost, "/admin/users/999/roles", `{"role":"custom"}`, http.StatusNotFound},
+		{http.MethodDelete, "/admin/users/abc/roles/custom", "", http.StatusBadRequest},
+		{http.MethodDelete, "/admin/users/999/roles/custom", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s status = %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+		}
+	}
+
+	var remaining models.Role
+	if err := db.First(&remaining, role.ID).Error; err != nil || remaining.Name != "custom" {
+		t.Errorf("role was modified by a malformed id: %+v, %v", remaining, err)
+	}
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"main/config"
+	"main/lockout"
+	"main/logger"
+	"main/models"
+	"main/utils"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// setupTestEnv は PostgreSQL の代わりに SQLite を config.DB に設定し、
+// コントローラーが参照する設定・署名鍵・ログイン試行の制限を初期化する
+func setupTestEnv(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+	config.LoadAuthConfig()
+
+	dsn := filepath.Join(t.TempDir(), "auth.db") + "?_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get test database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.OneTimeToken{}, &models.RecoveryCode{}, &models.LoginAttempt{}, &models.Role{}, &models.Permission{}, &models.UserIdentity{}, &models.OIDCAuthRequest{}, &models.PasswordHistory{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	if err := db.Create(&models.Role{Name: config.Auth.DefaultRole}).Error; err != nil {
+		t.Fatalf("failed to create default role: %v", err)
+	}
+	config.DB = db
+
+	if err := utils.InitKeyring("", ""); err != nil {
+		t.Fatalf("failed to create signing key: %v", err)
+	}
+
+	policy := lockout.Policy{MaxFailures: 5, BaseDelay: time.Second, LockoutDuration: time.Minute, Window: time.Minute}
+	lockout.Default = lockout.NewLimiter(lockout.NewMemoryStore(time.Minute), policy, policy)
+	return db
+}
+
+// countRows はテーブルの行数を返す。論理削除された行も数える。
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+
+	var n int64
+	if err := db.Unscoped().Model(model).Count(&n).Error; err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	return n
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"errors"
+	"main/config"
+	"main/logger"
+	"main/models"
+	"main/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const refreshTokenCookie = "refresh_token"
+
+var (
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reused")
+	errAccountDisabled     = errors.New("account is disabled")
+)
+
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// issueTokenPair はアクセストークンと新しいファミリーのリフレッシュトークンを発行する
+func issueTokenPair(db *gorm.DB, userID uint) (*tokenPair, error) {
+	familyID, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, _, err := createRefreshTokenRecord(db, userID, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := generateAccessToken(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// generateAccessToken はユーザーの現在のロールと権限を埋め込んだアクセストークンを発行する
+func generateAccessToken(db *gorm.DB, userID uint) (string, error) {
+	roles, permissions, err := loadAuthorities(db, userID)
+	if err != nil {
+		return "", err
+	}
+	return utils.GenerateToken(userID, roles, permissions)
+}
+
+// createRefreshTokenRecord はリフレッシュトークンを生成し、ハッシュをDBに保存する
+func createRefreshTokenRecord(db *gorm.DB, userID uint, familyID string) (string, *models.RefreshToken, error) {
+	token, hash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	record := models.RefreshToken{
+		UserID:    userID,
+		TokenHash: hash,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(config.Auth.RefreshTokenTTL),
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return "", nil, err
+	}
+	return token, &record, nil
+}
+
+// revokeRefreshTokenFamily は同じファミリーの有効なリフレッシュトークンを全て失効させる
+func revokeRefreshTokenFamily(db *gorm.DB, familyID string) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// revokeAllSessions はユーザーの全てのリフレッシュトークンと発行済みアクセストークンを無効にする
+func revokeAllSessions(db *gorm.DB, userID uint) error {
+	now := time.Now()
+	if err := db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	return db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("tokens_invalid_before", now).Error
+}
+
+// setRefreshTokenCookie はリフレッシュトークンをHttpOnlyクッキーとして設定する
+func setRefreshTokenCookie(c *gin.Context, token string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(refreshTokenCookie, token, int(config.Auth.RefreshTokenTTL.Seconds()), "/auth", "", config.Auth.CookieSecure, true)
+}
+
+func clearRefreshTokenCookie(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(refreshTokenCookie, "", -1, "/auth", "", config.Auth.CookieSecure, true)
+}
+
+// readRefreshToken はリクエストボディまたはクッキーからリフレッシュトークンを取得する
+func readRefreshToken(c *gin.Context) string {
+	var input struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.ShouldBindJSON(&input); err == nil && input.RefreshToken != "" {
+		return input.RefreshToken
+	}
+
+	token, err := c.Cookie(refreshTokenCookie)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+func Refresh(c *gin.Context) {
+	rawToken := readRefreshToken(c)
+	if rawToken == "" {
+		logger.Log.Warn("Missing refresh token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "リフレッシュトークンがありません"})
+		return
+	}
+
+	var current models.RefreshToken
+	var nextToken string
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", utils.HashToken(rawToken)).
+			First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvalidRefreshToken
+			}
+			return err
+		}
+
+		// 失効済みトークンの再利用は漏洩とみなす
+		if current.RevokedAt != nil {
+			return errRefreshTokenReused
+		}
+
+		if time.Now().After(current.ExpiresAt) {
+			return errInvalidRefreshToken
+		}
+
+		// 発行後に無効化・削除されたアカウントのトークンは更新しない
+		var user models.User
+		if err := tx.First(&user, current.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errInvalidRefreshToken
+			}
+			return err
+		}
+		if user.IsDisabled() {
+			return errAccountDisabled
+		}
+
+		token, next, err := createRefreshTokenRecord(tx, current.UserID, current.FamilyID)
+		if err != nil {
+			return err
+		}
+		nextToken = token
+
+		now := time.Now()
+		return tx.Model(&current).Updates(models.RefreshToken{RevokedAt: &now, ReplacedByID: &next.ID}).Error
+	})
+
+	switch {
+	case errors.Is(err, errRefreshTokenReused):
+		logger.Log.Warn("Refresh token reuse detected",
+			zap.Uint("userID", current.UserID), zap.String("familyID", current.FamilyID))
+		if err := revokeRefreshTokenFamily(config.DB, current.FamilyID); err != nil {
+			logger.Log.Error("Failed to revoke refresh token family", zap.Error(err))
+		}
+		clearRefreshTokenCookie(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なリフレッシュトークンです"})
+		return
+	case errors.Is(err, errAccountDisabled):
+		logger.Log.Info("Refresh for disabled account", zap.Uint("userID", current.UserID))
+		if err := revokeRefreshTokenFamily(config.DB, current.FamilyID); err != nil {
+			logger.Log.Error("Failed to revoke refresh token family", zap.Error(err))
+		}
+		clearRefreshTokenCookie(c)
+		c.JSON(http.StatusForbidden, gin.H{"error": "このアカウントは無効化されています", "code": "account_disabled"})
+		return
+	case errors.Is(err, errInvalidRefreshToken):
+		logger.Log.Info("Invalid or expired refresh token")
+		clearRefreshTokenCookie(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なリフレッシュトークンです"})
+		return
+	case err != nil:
+		logger.Log.Error("Failed to rotate refresh token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	accessToken, err := generateAccessToken(config.DB, current.UserID)
+	if err != nil {
+		logger.Log.Error("Failed to generate token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "トークンの生成に失敗しました"})
+		return
+	}
+
+	logger.Log.Info("Refresh token rotated", zap.Uint("userID", current.UserID))
+	setRefreshTokenCookie(c, nextToken)
+	c.JSON(http.StatusOK, gin.H{
+		"token":         accessToken,
+		"refresh_token": nextToken,
+		"expires_in":    int(config.Auth.AccessTokenTTL.Seconds()),
+	})
+}
+
+func Logout(c *gin.Context) {
+	rawToken := readRefreshToken(c)
+	if rawToken == "" {
+		logger.Log.Warn("Missing refresh token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "リフレッシュトークンがありません"})
+		return
+	}
+
+	var stored models.RefreshToken
+	if err := config.DB.Where("token_hash = ?", utils.HashToken(rawToken)).First(&stored).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Log.Error("Database error during logout", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+			return
+		}
+		// 未知のトークンでもログアウト済みとして扱う
+		clearRefreshTokenCookie(c)
+		c.JSON(http.StatusOK, gin.H{"message": "ログアウトしました"})
+		return
+	}
+
+	if err := revokeRefreshTokenFamily(config.DB, stored.FamilyID); err != nil {
+		logger.Log.Error("Failed to revoke refresh token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("User logged out", zap.Uint("userID", stored.UserID))
+	clearRefreshTokenCookie(c)
+	c.JSON(http.StatusOK, gin.H{"message": "ログアウトしました"})
+}
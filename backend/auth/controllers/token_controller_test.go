@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"main/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// refresh はリフレッシュトークンで /auth/refresh を呼び出す
+func refresh(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(`{"refresh_token":"`+token+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func setupRefreshTest(t *testing.T) (*gorm.DB, *gin.Engine, *models.User, string) {
+	t.Helper()
+
+	db := setupTestEnv(t)
+	user := models.User{Email: "user@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	tokens, err := issueTokenPair(db, user.ID)
+	if err != nil {
+		t.Fatalf("issueTokenPair: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/auth/refresh", Refresh)
+	return db, router, &user, tokens.RefreshToken
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	_, router, _, token := setupRefreshTest(t)
+
+	if w := refresh(router, token); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if w := refresh(router, token); w.Code != http.StatusUnauthorized {
+		t.Errorf("reused token status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRefreshRejectsDisabledAccount(t *testing.T) {
+	db, router, user, token := setupRefreshTest(t)
+	if err := db.Model(user).Update("disabled_at", time.Now()).Error; err != nil {
+		t.Fatalf("failed to disable user: %v", err)
+	}
+
+	w := refresh(router, token)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "account_disabled") {
+		t.Fatalf("status = %d body = %s, want %d account_disabled", w.Code, w.Body.String(), http.StatusForbidden)
+	}
+
+	var active int64
+	db.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&active)
+	if active != 0 {
+		t.Errorf("active refresh tokens = %d, want 0", active)
+	}
+}
+
+func TestRefreshRejectsDeletedAccount(t *testing.T) {
+	db, router, user, token := setupRefreshTest(t)
+	if err := db.Delete(user).Error; err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+
+	if w := refresh(router, token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"errors"
+	"main/config"
+	"main/logger"
+	"main/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUsersPerPage = 20
+	maxUsersPerPage     = 100
+)
+
+// parseIDParam はパスパラメータの ID を数値に変換する。
+// 文字列のまま First に渡すと SQL の条件として解釈されるため、必ずこれを通す。
+// 不正な値の場合は 400 を書き込んで false を返す。
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID の値が正しくありません"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// adminTargetUser はパスパラメータのユーザーを読み込む。失敗時はレスポンスを書き込んで false を返す。
+func adminTargetUser(c *gin.Context, db *gorm.DB) (*models.User, bool) {
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, false
+	}
+
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Log.Error("Failed to load user", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+			return nil, false
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "ユーザーが見つかりません"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// rejectSelfModification は管理者自身を無効化・削除しようとした場合に 400 を返して true を返す
+func rejectSelfModification(c *gin.Context, user *models.User) bool {
+	adminID, _ := c.Get("userID")
+	if id, ok := adminID.(uint); ok && id == user.ID {
+		logger.Log.Warn("Admin attempted to modify own account", zap.Uint("userID", user.ID))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "自分自身のアカウントは操作できません"})
+		return true
+	}
+	return false
+}
+
+func ListUsers(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page の値が正しくありません"})
+		return
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultUsersPerPage)))
+	if err != nil || perPage < 1 || perPage > maxUsersPerPage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "per_page は1から100の範囲で指定してください"})
+		return
+	}
+
+	query := config.DB.Model(&models.User{})
+	if email := strings.TrimSpace(c.Query("email")); email != "" {
+		// LIKE のワイルドカードはそのまま検索文字として扱う
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(email)
+		query = query.Where("email ILIKE ?", "%"+escaped+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Log.Error("Failed to count users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	var users []models.User
+	if err := query.Preload("Roles").
+		Order("id").
+		Limit(perPage).
+		Offset((page - 1) * perPage).
+		Find(&users).Error; err != nil {
+		logger.Log.Error("Failed to list users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":    users,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+func GetUserByID(c *gin.Context) {
+	user, ok := adminTargetUser(c, config.DB.Preload("Roles.Permissions"))
+	if !ok {
+		return
+	}
+
+	var identities []models.UserIdentity
+	if err := config.DB.Where("user_id = ?", user.ID).Order("id").Find(&identities).Error; err != nil {
+		logger.Log.Error("Failed to load user identities", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "identities": identities})
+}
+
+func DisableUser(c *gin.Context) {
+	user, ok := adminTargetUser(c, config.DB)
+	if !ok {
+		return
+	}
+	if rejectSelfModification(c, user) {
+		return
+	}
+	if user.IsDisabled() {
+		c.JSON(http.StatusOK, gin.H{"message": "アカウントは既に無効化されています"})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("disabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, user.ID)
+	})
+	if err != nil {
+		logger.Log.Error("Failed to disable user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	logger.Log.Info("User disabled", zap.Any("adminID", adminID), zap.Uint("userID", user.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "アカウントを無効化しました"})
+}
+
+func EnableUser(c *gin.Context) {
+	user, ok := adminTargetUser(c, config.DB)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Model(user).Update("disabled_at", nil).Error; err != nil {
+		logger.Log.Error("Failed to enable user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	logger.Log.Info("User enabled", zap.Any("adminID", adminID), zap.Uint("userID", user.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "アカウントを有効化しました"})
+}
+
+// ForceUserPasswordReset は全セッションを無効化し、再設定が済むまでパスワードでのログインを止めて再設定メールを送る
+func ForceUserPasswordReset(c *gin.Context) {
+	user, ok := adminTargetUser(c, config.DB)
+	if !ok {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password_reset_required", true).Error; err != nil {
+			return err
+		}
+		return revokeAllSessions(tx, user.ID)
+	})
+	if err != nil {
+		logger.Log.Error("Failed to force password reset", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	logger.Log.Info("Password reset forced", zap.Any("adminID", adminID), zap.Uint("userID", user.ID))
+
+	if err := sendPasswordResetEmail(c.Request.Context(), user); err != nil {
+		logger.Log.Error("Failed to send password reset email", zap.Error(err))
+		c.JSON(http.StatusAccepted, gin.H{"message": "パスワードの再設定を要求しましたが、メールの送信に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "パスワードの再設定を要求しました"})
+}
+
+// DeleteUser はユーザーを論理削除する。削除されたユーザーのトークンは AuthMiddleware で拒否される。
+func DeleteUser(c *gin.Context) {
+	user, ok := adminTargetUser(c, config.DB)
+	if !ok {
+		return
+	}
+	if rejectSelfModification(c, user) {
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := revokeAllSessions(tx, user.ID); err != nil {
+			return err
+		}
+		return tx.Delete(user).Error
+	})
+	if err != nil {
+		logger.Log.Error("Failed to delete user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	adminID, _ := c.Get("userID")
+	logger.Log.Info("User deleted", zap.Any("adminID", adminID), zap.Uint("userID", user.ID))
+	c.JSON(http.StatusOK, gin.H{"message": "ユーザーを削除しました"})
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"main/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminUserRoutesValidateID(t *testing.T) {
+	db := setupTestEnv(t)
+	user := models.User{Email: "user@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/admin/users/:id", GetUserByID)
+	router.DELETE("/admin/users/:id", DeleteUser)
+
+	tests := []struct {
+		method string
+		id     string
+		want   int
+	}{
+		{http.MethodGet, "1=1", http.StatusBadRequest},
+		{http.MethodGet, "id>0", http.StatusBadRequest},
+		{http.MethodGet, "abc", http.StatusBadRequest},
+		{http.MethodGet, "0", http.StatusBadRequest},
+		{http.MethodGet, "999", http.StatusNotFound},
+		{http.MethodDelete, "1=1", http.StatusBadRequest},
+		{http.MethodGet, "1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, "/admin/users/"+tt.id, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s /admin/users/%s status = %d, want %d: %s", tt.method, tt.id, w.Code, tt.want, w.Body.String())
+		}
+	}
+
+	if n := countRows(t, db, &models.User{}); n != 1 {
+		t.Errorf("users = %d, want 1", n)
+	}
+	var remaining models.User
+	if err := db.First(&remaining, user.ID).Error; err != nil {
+		t.Errorf("user was deleted by a malformed id: %v", err)
+	}
+}
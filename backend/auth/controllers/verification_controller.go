@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/config"
+	"main/logger"
+	"main/mail"
+	"main/models"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// verificationMailTimeout は応答後に行う確認メールの再送の制限時間
+const verificationMailTimeout = time.Minute
+
+// sendVerificationEmail は確認用トークンを発行してメールで送る
+func sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := issueOneTimeToken(config.DB, user.ID, models.TokenPurposeEmailVerification, config.Auth.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", config.Auth.AppBaseURL, url.QueryEscape(token))
+	return mail.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "メールアドレスの確認",
+		Body:    fmt.Sprintf("以下のリンクからメールアドレスを確認してください。\n\n%s\n\nこのリンクの有効期限は%sです。\n", link, config.Auth.EmailVerificationTTL),
+	})
+}
+
+func VerifyEmail(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for email verification", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var userID uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeOneTimeToken(tx, input.Token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		userID = token.UserID
+
+		return tx.Model(&models.User{}).
+			Where("id = ? AND verified_at IS NULL", token.UserID).
+			Update("verified_at", time.Now()).Error
+	})
+	if errors.Is(err, errInvalidOneTimeToken) {
+		logger.Log.Info("Invalid or expired email verification token")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "確認リンクが無効か、有効期限が切れています"})
+		return
+	}
+	if err != nil {
+		logger.Log.Error("Failed to verify email", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "サーバーエラーが発生しました"})
+		return
+	}
+
+	logger.Log.Info("Email verified", zap.Uint("userID", userID))
+	c.JSON(http.StatusOK, gin.H{"message": "メールアドレスを確認しました"})
+}
+
+func ResendVerificationEmail(c *gin.Context) {
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		logger.Log.Warn("Invalid input for resending verification email", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 登録の有無や確認済みかどうかで応答の内容・時間が変わらないよう、検索と送信は応答後に行う。
+	// リクエストの終了で取り消されないよう、キャンセルを引き継がないコンテキストを使う。
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), verificationMailTimeout)
+	go func() {
+		defer cancel()
+		resendVerificationEmail(ctx, input.Email)
+	}()
+
+	c.JSON(http.StatusOK, gin.H{"message": "確認メールを送信しました"})
+}
+
+// resendVerificationEmail は未確認のアカウントであれば確認メールを送り直す。
+// 結果は応答に含めず、ログにだけ残す。
+func resendVerificationEmail(ctx context.Context, email string) {
+	var user models.User
+	if err := config.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Log.Error("Database error during verification resend", zap.Error(err))
+		} else {
+			logger.Log.Info("Verification resend for non-existent email", zap.String("email", email))
+		}
+		return
+	}
+
+	if user.VerifiedAt != nil {
+		logger.Log.Info("Verification resend for verified email", zap.String("email", email))
+		return
+	}
+
+	// 短時間に連続で送らない
+	lastIssuedAt, found, err := lastOneTimeTokenIssuedAt(config.DB.WithContext(ctx), user.ID, models.TokenPurposeEmailVerification)
+	if err != nil {
+		logger.Log.Error("Database error during verification resend", zap.Error(err))
+		return
+	}
+	if found && time.Since(lastIssuedAt) < config.Auth.EmailResendInterval {
+		logger.Log.Info("Verification resend throttled", zap.Uint("userID", user.ID))
+		return
+	}
+
+	if err := sendVerificationEmail(ctx, &user); err != nil {
+		logger.Log.Error("Failed to send verification email", zap.Error(err))
+		return
+	}
+
+	logger.Log.Info("Verification email resent", zap.Uint("userID", user.ID))
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"context"
+	"main/mail"
+	"main/models"
+	"testing"
+	"time"
+)
+
+func TestResendVerificationEmailThrottlesSilently(t *testing.T) {
+	db := setupTestEnv(t)
+	sender := mail.NewMemorySender()
+	mail.Default = sender
+
+	user := models.User{Email: "user@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	resendVerificationEmail(context.Background(), user.Email)
+	resendVerificationEmail(context.Background(), user.Email)
+
+	if got := len(sender.Messages()); got != 1 {
+		t.Fatalf("sent %d messages, want 1", got)
+	}
+	if got := countRows(t, db, &models.OneTimeToken{}); got != 1 {
+		t.Errorf("issued %d tokens, want 1", got)
+	}
+}
+
+func TestResendVerificationEmailSkipsVerifiedAndUnknownAccounts(t *testing.T) {
+	db := setupTestEnv(t)
+	sender := mail.NewMemorySender()
+	mail.Default = sender
+
+	verifiedAt := time.Now()
+	user := models.User{Email: "verified@example.com", VerifiedAt: &verifiedAt}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	resendVerificationEmail(context.Background(), user.Email)
+	resendVerificationEmail(context.Background(), "unknown@example.com")
+
+	if got := len(sender.Messages()); got != 0 {
+		t.Errorf("sent %d messages, want 0", got)
+	}
+}
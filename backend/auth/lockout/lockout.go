@@ -0,0 +1,113 @@
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// State はキーごとのログイン失敗状況
+type State struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// Policy は失敗回数に応じたバックオフとロックの設定
+type Policy struct {
+	// この回数に達すると LockoutDuration の間ロックする
+	MaxFailures int
+	// 失敗ごとの待ち時間の初期値。失敗のたびに倍になる
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+	// 最後の失敗からこの時間が経つと失敗回数をリセットする
+	Window time.Duration
+}
+
+// LockUntil は failures 回目の失敗後にログインを受け付けない期限を返す
+func (p Policy) LockUntil(failures int, now time.Time) time.Time {
+	if failures >= p.MaxFailures {
+		return now.Add(p.LockoutDuration)
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < failures && delay < p.LockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > p.LockoutDuration {
+		delay = p.LockoutDuration
+	}
+	return now.Add(delay)
+}
+
+// Store は失敗回数の保存先。複数レプリカで共有する場合は DB 実装を使う。
+type Store interface {
+	Get(ctx context.Context, key string) (State, error)
+	// RecordFailure は失敗を1回記録し、ポリシーに従ってロック期限を更新した状態を返す
+	RecordFailure(ctx context.Context, key string, policy Policy, now time.Time) (State, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// Limiter はアカウント単位と IP 単位の失敗回数をまとめて扱う
+type Limiter struct {
+	store   Store
+	account Policy
+	ip      Policy
+}
+
+var Default *Limiter
+
+func NewLimiter(store Store, account, ip Policy) *Limiter {
+	return &Limiter{store: store, account: account, ip: ip}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check はログインを試行できるまでの残り時間を返す。0 なら試行可能。
+func (l *Limiter) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		state, err := l.store.Get(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if d := state.LockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// Fail はアカウントと IP の両方に失敗を記録する
+func (l *Limiter) Fail(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	if _, err := l.store.RecordFailure(ctx, accountKey(email), l.account, now); err != nil {
+		return err
+	}
+	_, err := l.store.RecordFailure(ctx, ipKey(ip), l.ip, now)
+	return err
+}
+
+// Succeed はログイン成功時にアカウントの失敗回数をリセットする。
+// IP の失敗回数は他のアカウントへの試行を含むためリセットしない。
+func (l *Limiter) Succeed(ctx context.Context, email string) error {
+	return l.store.Reset(ctx, accountKey(email))
+}
+
+// UnlockAccount は管理者によるアカウントのロック解除
+func (l *Limiter) UnlockAccount(ctx context.Context, email string) error {
+	return l.store.Reset(ctx, accountKey(email))
+}
+
+// UnlockIP は管理者による IP のロック解除
+func (l *Limiter) UnlockIP(ctx context.Context, ip string) error {
+	return l.store.Reset(ctx, ipKey(ip))
+}
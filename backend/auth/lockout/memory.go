@@ -0,0 +1,82 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxPruneInterval は期限切れのエントリを掃除する間隔の上限
+const maxPruneInterval = time.Minute
+
+// MemoryStore はプロセス内で失敗回数を保持する。単一レプリカやテスト向け。
+// 最後の失敗から ttl が経過し、ロックも解けたエントリは定期的に削除する。
+type MemoryStore struct {
+	mu        sync.Mutex
+	states    map[string]State
+	ttl       time.Duration
+	nextPrune time.Time
+}
+
+// NewMemoryStore は ttl 経過後に失敗の記録を破棄するストアを作成する。
+// ttl には失敗回数を数える期間とロック期間の長い方を指定する。
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{states: make(map[string]State), ttl: ttl}
+}
+
+// expired はエントリがもう判定に使われないかどうかを返す
+func (s *MemoryStore) expired(state State, now time.Time) bool {
+	return now.Sub(state.LastFailureAt) > s.ttl && !now.Before(state.LockedUntil)
+}
+
+// prune は期限切れのエントリを削除する。呼び出し側でロックを取ること。
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Before(s.nextPrune) {
+		return
+	}
+	for key, state := range s.states {
+		if s.expired(state, now) {
+			delete(s.states, key)
+		}
+	}
+	s.nextPrune = now.Add(pruneInterval(s.ttl))
+}
+
+// pruneInterval は ttl に応じた掃除の間隔を返す
+func pruneInterval(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > maxPruneInterval {
+		return maxPruneInterval
+	}
+	return ttl
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[key], nil
+}
+
+func (s *MemoryStore) RecordFailure(ctx context.Context, key string, policy Policy, now time.Time) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	state := s.states[key]
+	if now.Sub(state.LastFailureAt) > policy.Window {
+		state.Failures = 0
+	}
+	state.Failures++
+	state.LastFailureAt = now
+	state.LockedUntil = policy.LockUntil(state.Failures, now)
+
+	s.states[key] = state
+	return state, nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
@@ -0,0 +1,106 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"main/logger"
+	"main/models"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresStore は login_attempts テーブルで失敗回数を共有する。
+// 最後の失敗から ttl が経過し、ロックも解けた行は定期的に削除する。
+type PostgresStore struct {
+	db  *gorm.DB
+	ttl time.Duration
+
+	mu        sync.Mutex
+	nextPrune time.Time
+}
+
+// NewPostgresStore は ttl 経過後に失敗の記録を削除するストアを作成する。
+// ttl には失敗回数を数える期間とロック期間の長い方を指定する。
+func NewPostgresStore(db *gorm.DB, ttl time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, ttl: ttl}
+}
+
+// prune は期限切れの行を削除する。失敗してもログインの記録は続ける。
+func (s *PostgresStore) prune(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	if now.Before(s.nextPrune) {
+		s.mu.Unlock()
+		return
+	}
+	s.nextPrune = now.Add(pruneInterval(s.ttl))
+	s.mu.Unlock()
+
+	result := s.db.WithContext(ctx).
+		Where("last_failure_at < ? AND locked_until <= ?", now.Add(-s.ttl), now).
+		Delete(&models.LoginAttempt{})
+	if result.Error != nil {
+		logger.Log.Error("Failed to purge expired login attempts", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.Log.Info("Purged expired login attempts", zap.Int64("count", result.RowsAffected))
+	}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (State, error) {
+	var attempt models.LoginAttempt
+	err := s.db.WithContext(ctx).Where("key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+	return toState(attempt), nil
+}
+
+func (s *PostgresStore) RecordFailure(ctx context.Context, key string, policy Policy, now time.Time) (State, error) {
+	s.prune(ctx, now)
+
+	var attempt models.LoginAttempt
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 行がなければ作成してから行ロックを取る
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.LoginAttempt{Key: key, LastFailureAt: now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).First(&attempt).Error; err != nil {
+			return err
+		}
+
+		if now.Sub(attempt.LastFailureAt) > policy.Window {
+			attempt.Failures = 0
+		}
+		attempt.Failures++
+		attempt.LastFailureAt = now
+		attempt.LockedUntil = policy.LockUntil(attempt.Failures, now)
+
+		return tx.Save(&attempt).Error
+	})
+	if err != nil {
+		return State{}, err
+	}
+	return toState(attempt), nil
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+func toState(attempt models.LoginAttempt) State {
+	return State{
+		Failures:      attempt.Failures,
+		LastFailureAt: attempt.LastFailureAt,
+		LockedUntil:   attempt.LockedUntil,
+	}
+}
@@ -0,0 +1,54 @@
+package lockout
+
+import (
+	"context"
+	"main/logger"
+	"main/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestPostgresStorePurgesExpiredAttempts(t *testing.T) {
+	logger.Log = zap.NewNop()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "lockout.db")), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.LoginAttempt{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	ctx := context.Background()
+	policy := Policy{MaxFailures: 2, BaseDelay: time.Second, LockoutDuration: time.Hour, Window: time.Minute}
+	store := NewPostgresStore(db, time.Hour)
+	now := time.Now()
+
+	// stale は失敗回数もロックも期限切れになり、recent は ttl 内に失敗している
+	for i := 0; i < policy.MaxFailures; i++ {
+		if _, err := store.RecordFailure(ctx, "stale", policy, now); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	if _, err := store.RecordFailure(ctx, "recent", policy, now.Add(90*time.Minute)); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+
+	later := now.Add(2 * time.Hour)
+	if _, err := store.RecordFailure(ctx, "fresh", policy, later); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+
+	var keys []string
+	if err := db.Model(&models.LoginAttempt{}).Order("key").Pluck("key", &keys).Error; err != nil {
+		t.Fatalf("failed to list login attempts: %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "fresh" || keys[1] != "recent" {
+		t.Errorf("remaining keys = %v, want [fresh recent]", keys)
+	}
+}
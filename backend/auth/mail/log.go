@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"main/logger"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// tokenPattern はメール本文のリンクに含まれるワンタイムトークン
+var tokenPattern = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+// LogSender はメールを送信せずログに記録する。SMTP を用意していない環境向け。
+// ログからアカウントを乗っ取れないよう、本文のトークンは伏せ字にする。
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	logger.Log.Info("Mail not sent (MAIL_DRIVER=log)",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", redactTokens(msg.Body)),
+	)
+	return nil
+}
+
+// redactTokens は本文中のトークンを伏せ字に置き換える
+func redactTokens(body string) string {
+	return tokenPattern.ReplaceAllString(body, "${1}[REDACTED]")
+}
@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// Message は送信するメール
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender はメール送信の実装を差し替えるためのインターフェース
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var Default Sender
+
+// Init は MAIL_DRIVER に応じて送信実装を選択する。
+// 未指定の場合はメールを送らずログに記録する（トークンは伏せ字）。
+func Init() {
+	switch strings.ToLower(os.Getenv("MAIL_DRIVER")) {
+	case "smtp":
+		Default = NewSMTPSender(
+			os.Getenv("SMTP_HOST"),
+			os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USERNAME"),
+			os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("MAIL_FROM"),
+		)
+	case "memory":
+		Default = NewMemorySender()
+	default:
+		Default = NewLogSender()
+	}
+}
+
+// Send は既定の送信実装でメールを送る
+func Send(ctx context.Context, msg Message) error {
+	return Default.Send(ctx, msg)
+}
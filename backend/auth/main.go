@@ -3,8 +3,13 @@ package main
 import (
 	"log"
 	"main/config"
+	"main/lockout"
 	"main/logger"
+	"main/mail"
+	"main/oidc"
+	"main/passwordpolicy"
 	"main/routes"
+	"main/utils"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -24,12 +29,83 @@ func main() {
 	logger.Init()
 	defer logger.Log.Sync()
 
+	// 認証設定の読み込み
+	config.LoadAuthConfig()
+
+	// JWT署名鍵の読み込み
+	if err := utils.InitKeyring(config.Auth.JWTKeysDir, config.Auth.JWTActiveKID); err != nil {
+		logger.Log.Fatal("Failed to load JWT signing keys", zap.Error(err))
+	}
+	if config.Auth.JWTKeysDir == "" {
+		logger.Log.Warn("JWT_KEYS_DIR is not set; using an ephemeral signing key", zap.String("kid", utils.Keys.Active().ID))
+	}
+
+	// パスワードハッシュの初期化
+	if err := utils.InitPasswordHashers(config.Auth.PasswordHashAlgorithm, utils.Argon2idHasher{
+		Memory:      uint32(config.Auth.Argon2Memory),
+		Iterations:  uint32(config.Auth.Argon2Iterations),
+		Parallelism: uint8(config.Auth.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	}, config.Auth.BcryptCost); err != nil {
+		logger.Log.Fatal("Failed to initialize password hasher", zap.Error(err))
+	}
+
+	// メール送信の初期化
+	mail.Init()
+	switch mail.Default.(type) {
+	case *mail.LogSender:
+		logger.Log.Warn("MAIL_DRIVER is not smtp; outgoing mail is only logged with tokens redacted")
+	case *mail.MemorySender:
+		logger.Log.Warn("MAIL_DRIVER=memory; outgoing mail is kept in memory only")
+	}
+
+	// 外部 IdP（OIDC）の初期化
+	oidc.Init()
+
+	// パスワードポリシーの読み込み
+	if err := passwordpolicy.Init(); err != nil {
+		logger.Log.Fatal("Failed to load password policy", zap.Error(err))
+	}
+
 	// データベース接続
 	config.ConnectDatabase()
 
+	// ログイン試行回数の制限
+	attemptTTL := config.Auth.LoginFailureWindow
+	if config.Auth.LoginLockoutDuration > attemptTTL {
+		attemptTTL = config.Auth.LoginLockoutDuration
+	}
+	var attemptStore lockout.Store
+	if config.Auth.LoginAttemptStore == "memory" {
+		attemptStore = lockout.NewMemoryStore(attemptTTL)
+	} else {
+		attemptStore = lockout.NewPostgresStore(config.DB, attemptTTL)
+	}
+	lockout.Default = lockout.NewLimiter(attemptStore,
+		lockout.Policy{
+			MaxFailures:     config.Auth.LoginMaxFailures,
+			BaseDelay:       config.Auth.LoginBackoffBase,
+			LockoutDuration: config.Auth.LoginLockoutDuration,
+			Window:          config.Auth.LoginFailureWindow,
+		},
+		lockout.Policy{
+			// 同じ IP を共有する利用者を巻き込まないよう IP はしきい値でのみロックする
+			MaxFailures:     config.Auth.LoginMaxFailuresPerIP,
+			LockoutDuration: config.Auth.LoginLockoutDuration,
+			Window:          config.Auth.LoginFailureWindow,
+		},
+	)
+
 	// Ginのインスタンス作成
 	r := gin.Default()
 
+	// X-Forwarded-For は TRUSTED_PROXIES に含まれるプロキシからのものだけを信用する。
+	// 未設定の場合は接続元のアドレスをそのままクライアント IP とする。
+	if err := r.SetTrustedProxies(config.Auth.TrustedProxies); err != nil {
+		logger.Log.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
+
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
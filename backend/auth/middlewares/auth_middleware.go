@@ -1,7 +1,10 @@
 package middlewares
 
 import (
+	"errors"
+	"main/config"
 	"main/logger"
+	"main/models"
 	"main/utils"
 	"net/http"
 	"strings"
@@ -38,16 +41,67 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		userID, err := utils.ParseToken(tokenStr)
+		claims, err := utils.ParseToken(tokenStr)
 		if err != nil {
-			logger.Log.Warn("Invalid token", zap.Error(err))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なトークンです"})
+			code, message := tokenErrorReason(err)
+			logger.Log.Warn("Invalid token", zap.String("reason", code), zap.Error(err))
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": message, "code": code})
+			c.Abort()
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			logger.Log.Warn("Invalid token subject", zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なトークンです", "code": "invalid_token"})
+			c.Abort()
+			return
+		}
+
+		// パスワード変更などで無効化されたトークンや、無効化・削除されたアカウントを拒否
+		var user models.User
+		if err := config.DB.Select("id", "tokens_invalid_before", "disabled_at").First(&user, userID).Error; err != nil {
+			logger.Log.Warn("Token for unknown user", zap.Uint("userID", userID), zap.Error(err))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "無効なトークンです", "code": "invalid_token"})
+			c.Abort()
+			return
+		}
+		if user.TokensInvalidBefore != nil && claims.IssuedAt < user.TokensInvalidBefore.Unix() {
+			logger.Log.Warn("Revoked token", zap.Uint("userID", userID))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "トークンは無効化されています", "code": "token_revoked"})
+			c.Abort()
+			return
+		}
+
+		if user.IsDisabled() {
+			logger.Log.Warn("Token for disabled account", zap.Uint("userID", userID))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "このアカウントは無効化されています", "code": "account_disabled"})
 			c.Abort()
 			return
 		}
 
 		logger.Log.Debug("User authenticated", zap.Uint("userID", userID))
 		c.Set("userID", userID)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
+
+// tokenErrorReason はトークン検証エラーをレスポンス用のコードとメッセージに変換する
+func tokenErrorReason(err error) (string, string) {
+	switch {
+	case errors.Is(err, utils.ErrTokenExpired):
+		return "token_expired", "トークンの有効期限が切れています"
+	case errors.Is(err, utils.ErrTokenSignature):
+		return "invalid_signature", "トークンの署名が無効です"
+	case errors.Is(err, utils.ErrTokenAudience):
+		return "invalid_audience", "トークンの対象が正しくありません"
+	case errors.Is(err, utils.ErrTokenIssuer):
+		return "invalid_issuer", "トークンの発行者が正しくありません"
+	case errors.Is(err, utils.ErrTokenNotYetValid):
+		return "token_not_yet_valid", "トークンはまだ有効ではありません"
+	default:
+		return "invalid_token", "無効なトークンです"
+	}
+}
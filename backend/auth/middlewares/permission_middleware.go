@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"main/logger"
+	"main/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequirePermission はトークンのクレームに指定の権限がなければ 403 を返す。AuthMiddleware の後に使う。
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("claims")
+		claims, ok := value.(*utils.Claims)
+		if !ok || !claims.HasPermission(permission) {
+			userID, _ := c.Get("userID")
+			logger.Log.Warn("Permission denied", zap.Any("userID", userID), zap.String("permission", permission))
+			c.JSON(http.StatusForbidden, gin.H{"error": "権限がありません", "code": "forbidden"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// LoginAttempt はアカウントまたは IP ごとのログイン失敗回数
+type LoginAttempt struct {
+	Key           string    `gorm:"primaryKey;size:320"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+	LockedUntil   time.Time `gorm:"not null"`
+}
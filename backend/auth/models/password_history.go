@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PasswordHistory は再利用を防ぐため過去のパスワードハッシュを保持する
+type PasswordHistory struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Hash      string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"not null;index"`
+}
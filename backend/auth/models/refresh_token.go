@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken はリフレッシュトークンのハッシュを保持する。
+// 同じログインから発行されたトークンは FamilyID を共有し、
+// 再利用を検知した場合はファミリー全体を失効させる。
+type RefreshToken struct {
+	gorm.Model
+	UserID       uint      `gorm:"not null;index"`
+	TokenHash    string    `gorm:"size:64;not null;uniqueIndex"`
+	FamilyID     string    `gorm:"size:64;not null;index"`
+	ExpiresAt    time.Time `gorm:"not null"`
+	RevokedAt    *time.Time
+	ReplacedByID *uint
+}
@@ -0,0 +1,40 @@
+package models
+
+import "gorm.io/gorm"
+
+// 権限名。"リソース:操作" の形式
+const (
+	PermissionIncidentRead   = "incident:read"
+	PermissionIncidentWrite  = "incident:write"
+	PermissionIncidentDelete = "incident:delete"
+	PermissionUserAdmin      = "user:admin"
+)
+
+// 既定のロール名
+const (
+	RoleAdmin     = "admin"
+	RoleResponder = "responder"
+	RoleViewer    = "viewer"
+)
+
+type Permission struct {
+	gorm.Model
+	Name        string `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Description string `json:"description"`
+}
+
+type Role struct {
+	gorm.Model
+	Name        string       `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+}
+
+// PermissionNames はロールが持つ権限名の一覧を返す
+func (r *Role) PermissionNames() []string {
+	names := make([]string, len(r.Permissions))
+	for i, p := range r.Permissions {
+		names[i] = p.Name
+	}
+	return names
+}
@@ -1,9 +1,34 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
-	Email    string `gorm:"uniqueIndex" json:"email"`
-	Password string `json:"-"`
+	Email      string     `gorm:"uniqueIndex" json:"email"`
+	Password   string     `json:"-"`
+	VerifiedAt *time.Time `json:"verified_at"`
+	// メールアドレス変更の確認待ちの新しいアドレス
+	PendingEmail string `json:"-"`
+	// この時刻より前に発行されたアクセストークンは無効
+	TokensInvalidBefore *time.Time `json:"-"`
+	// 管理者により無効化されたアカウントはログインもトークンの利用もできない
+	DisabledAt *time.Time `json:"disabled_at"`
+	// 管理者がパスワードの再設定を要求した場合、再設定が済むまでパスワードでログインできない
+	PasswordResetRequired bool `json:"password_reset_required"`
+
+	// TOTP二段階認証。TOTPEnabledAt が設定されるまでは登録途中
+	TOTPSecret    string     `json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep  int64      `json:"-"`
+
+	Roles []Role `gorm:"many2many:user_roles" json:"roles,omitempty"`
+}
+
+// IsDisabled はアカウントが無効化されているか判定する
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentity は外部 IdP のアカウント（provider + sub）とユーザーの紐付け
+type UserIdentity struct {
+	gorm.Model
+	UserID   uint   `gorm:"not null;index" json:"user_id"`
+	Provider string `gorm:"size:64;not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject  string `gorm:"size:255;not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`
+	Email    string `json:"email"`
+}
+
+// OIDCAuthRequest は認可リクエストごとの state・nonce・PKCE verifier。
+// コールバックで一度だけ取り出して削除する。
+type OIDCAuthRequest struct {
+	ID           uint      `gorm:"primaryKey"`
+	State        string    `gorm:"size:64;not null;uniqueIndex"`
+	Provider     string    `gorm:"size:64;not null"`
+	Nonce        string    `gorm:"size:64;not null"`
+	CodeVerifier string    `gorm:"size:128;not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
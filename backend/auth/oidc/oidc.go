@@ -0,0 +1,130 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/config"
+	"sync"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnknownProvider は設定されていないプロバイダ名が指定されたことを示す
+var ErrUnknownProvider = errors.New("unknown oidc provider")
+
+// Provider は discovery ドキュメントから構築した IdP ごとのクライアント
+type Provider struct {
+	Name     string
+	OAuth2   *oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// Claims は ID トークンから取り出すクレーム
+type Claims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Nonce         string `json:"nonce"`
+}
+
+// Registry は設定されたプロバイダを保持し、discovery ドキュメントを初回利用時に読み込む
+type Registry struct {
+	mu        sync.Mutex
+	configs   map[string]config.OIDCProviderConfig
+	providers map[string]*Provider
+}
+
+var Default *Registry
+
+// Init は認証設定から Default のレジストリを構築する
+func Init() {
+	Default = NewRegistry(config.Auth.OIDCProviders)
+}
+
+func NewRegistry(providers []config.OIDCProviderConfig) *Registry {
+	configs := make(map[string]config.OIDCProviderConfig, len(providers))
+	for _, p := range providers {
+		configs[p.Name] = p
+	}
+	return &Registry{configs: configs, providers: make(map[string]*Provider)}
+}
+
+// Provider は名前に対応するプロバイダを返す。
+// discovery に失敗した場合はキャッシュせず、次回の呼び出しで再試行する。
+// 応答の遅い IdP が他のプロバイダの利用を妨げないよう、discovery の間はロックを保持しない。
+func (r *Registry) Provider(ctx context.Context, name string) (*Provider, error) {
+	r.mu.Lock()
+	p, cached := r.providers[name]
+	cfg, configured := r.configs[name]
+	r.mu.Unlock()
+
+	if cached {
+		return p, nil
+	}
+	if !configured {
+		return nil, ErrUnknownProvider
+	}
+
+	discovered, err := gooidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", name, err)
+	}
+
+	p = &Provider{
+		Name: name,
+		OAuth2: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       cfg.Scopes,
+		},
+		verifier: discovered.Verifier(&gooidc.Config{ClientID: cfg.ClientID}),
+	}
+
+	// 同時に discovery した呼び出しがあれば、先に登録されたものを使う
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.providers[name]; ok {
+		return existing, nil
+	}
+	r.providers[name] = p
+	return p, nil
+}
+
+// AuthCodeURL は state・nonce・PKCE（S256）を付けた認可エンドポイントの URL を返す
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	return p.OAuth2.AuthCodeURL(state,
+		gooidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(codeVerifier),
+	)
+}
+
+// Exchange は認可コードをトークンに交換し、ID トークンの署名・iss・aud・exp と nonce を検証する
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	token, err := p.OAuth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("code exchange: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("id_token verification: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+
+	var claims Claims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("id_token claims: %w", err)
+	}
+	return &claims, nil
+}
@@ -0,0 +1,132 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/config"
+	"main/oidc"
+	"main/oidc/oidctest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func providerConfig(name, issuer string) config.OIDCProviderConfig {
+	return config.OIDCProviderConfig{
+		Name:         name,
+		Issuer:       issuer,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/auth/oidc/" + name + "/callback",
+		Scopes:       []string{"openid", "email"},
+	}
+}
+
+func TestProviderUsesDiscoveredEndpoints(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "client-id")
+	registry := oidc.NewRegistry([]config.OIDCProviderConfig{providerConfig("test", issuer.URL())})
+
+	provider, err := registry.Provider(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Provider: %v", err)
+	}
+	if got, want := provider.OAuth2.Endpoint.TokenURL, issuer.URL()+"/token"; got != want {
+		t.Errorf("token endpoint = %q, want %q", got, want)
+	}
+
+	authURL := provider.AuthCodeURL("state", "nonce", "verifier")
+	if !strings.HasPrefix(authURL, issuer.URL()+"/authorize?") {
+		t.Errorf("auth URL = %q, want the discovered authorization endpoint", authURL)
+	}
+	grant, err := issuer.GrantFromURL(authURL, oidctest.Identity{Subject: "sub"})
+	if err != nil {
+		t.Fatalf("GrantFromURL: %v", err)
+	}
+	if grant.Nonce != "nonce" || grant.CodeChallenge != oidctest.CodeChallenge("verifier") {
+		t.Errorf("grant = %+v, want the nonce and the S256 challenge of the verifier", grant)
+	}
+}
+
+func TestProviderRejectsUnknownName(t *testing.T) {
+	registry := oidc.NewRegistry(nil)
+
+	if _, err := registry.Provider(context.Background(), "missing"); !errors.Is(err, oidc.ErrUnknownProvider) {
+		t.Fatalf("err = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestProviderCachesDiscoveryAndRetriesFailures(t *testing.T) {
+	// 最初の discovery だけ失敗する IdP
+	var requests atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"issuer":%q,"authorization_endpoint":%q,"token_endpoint":%q,"jwks_uri":%q}`,
+			server.URL, server.URL+"/authorize", server.URL+"/token", server.URL+"/jwks")
+	}))
+	defer server.Close()
+
+	registry := oidc.NewRegistry([]config.OIDCProviderConfig{providerConfig("test", server.URL)})
+
+	if _, err := registry.Provider(context.Background(), "test"); err == nil {
+		t.Fatal("expected the first discovery to fail")
+	}
+	first, err := registry.Provider(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Provider after a failed discovery: %v", err)
+	}
+	second, err := registry.Provider(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Provider: %v", err)
+	}
+	if first != second {
+		t.Error("expected the discovered provider to be cached")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("discovery requests = %d, want 2", got)
+	}
+}
+
+func TestProviderDoesNotBlockOnSlowDiscovery(t *testing.T) {
+	issuer := oidctest.NewIssuer(t, "client-id")
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	registry := oidc.NewRegistry([]config.OIDCProviderConfig{
+		providerConfig("slow", slow.URL),
+		providerConfig("fast", issuer.URL()),
+	})
+
+	go registry.Provider(context.Background(), "slow")
+	// slow の discovery が始まるのを待つ
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := registry.Provider(context.Background(), "fast")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Provider: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("discovery of one provider blocked another provider")
+	}
+}
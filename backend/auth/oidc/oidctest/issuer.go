@@ -0,0 +1,212 @@
+// Package oidctest はテスト用に discovery・JWKS・トークンエンドポイントを持つ OIDC の IdP を提供する
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const keyID = "oidctest"
+
+// Identity は IdP 側のユーザー
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// Grant は認可コードに結び付ける情報。テストで書き換えて不正な応答を再現できる。
+type Grant struct {
+	Identity      Identity
+	Nonce         string
+	CodeChallenge string
+}
+
+// Issuer は httptest.Server 上で動く IdP
+type Issuer struct {
+	ClientID string
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]Grant
+	seq   int
+}
+
+// NewIssuer は IdP を起動する。テストの終了時に停止する。
+func NewIssuer(t testing.TB, clientID string) *Issuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate signing key: %v", err)
+	}
+
+	i := &Issuer{ClientID: clientID, key: key, codes: make(map[string]Grant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", i.discovery)
+	mux.HandleFunc("/jwks", i.jwks)
+	mux.HandleFunc("/token", i.token)
+	i.server = httptest.NewServer(mux)
+	t.Cleanup(i.server.Close)
+	return i
+}
+
+// URL は issuer の URL
+func (i *Issuer) URL() string {
+	return i.server.URL
+}
+
+// GrantFromURL は認可エンドポイントへのリダイレクト先 URL から、ユーザーが同意した場合の Grant を作る
+func (i *Issuer) GrantFromURL(authURL string, identity Identity) (Grant, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return Grant{}, err
+	}
+	q := u.Query()
+	if q.Get("client_id") != i.ClientID {
+		return Grant{}, fmt.Errorf("unexpected client_id %q", q.Get("client_id"))
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		return Grant{}, errors.New("authorization request has no S256 code challenge")
+	}
+	if q.Get("nonce") == "" {
+		return Grant{}, errors.New("authorization request has no nonce")
+	}
+	return Grant{Identity: identity, Nonce: q.Get("nonce"), CodeChallenge: q.Get("code_challenge")}, nil
+}
+
+// IssueCode は Grant に対応する認可コードを発行する
+func (i *Issuer) IssueCode(grant Grant) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.seq++
+	code := fmt.Sprintf("code-%d", i.seq)
+	i.codes[code] = grant
+	return code
+}
+
+// Authorize は GrantFromURL と IssueCode をまとめて行う
+func (i *Issuer) Authorize(authURL string, identity Identity) (string, error) {
+	grant, err := i.GrantFromURL(authURL, identity)
+	if err != nil {
+		return "", err
+	}
+	return i.IssueCode(grant), nil
+}
+
+// CodeChallenge は code_verifier に対する S256 の code_challenge を返す
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                i.URL(),
+		"authorization_endpoint":                i.URL() + "/authorize",
+		"token_endpoint":                        i.URL() + "/token",
+		"jwks_uri":                              i.URL() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       &i.key.PublicKey,
+		KeyID:     keyID,
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}})
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, _, ok := r.BasicAuth()
+	if !ok {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != i.ClientID {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	// 認可コードは一度だけ使える
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	grant, ok := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+	if !ok || CodeChallenge(r.PostForm.Get("code_verifier")) != grant.CodeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := i.signIDToken(grant)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-" + code,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (i *Issuer) signIDToken(grant Grant) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: i.key, KeyID: keyID}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(map[string]interface{}{
+		"iss":            i.URL(),
+		"sub":            grant.Identity.Subject,
+		"aud":            i.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          grant.Nonce,
+		"email":          grant.Identity.Email,
+		"email_verified": grant.Identity.EmailVerified,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return signed.CompactSerialize()
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,61 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const hashPrefixLength = 5
+
+// BreachedChecker は漏洩済みパスワードかどうかを判定する
+type BreachedChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// PrefixFileChecker は Have I Been Pwned の range 形式のファイルを使ってオフラインで判定する。
+// dir には SHA-1 の先頭5桁（大文字16進）をファイル名とし、残り35桁とヒット数を
+// "SUFFIX:COUNT" の形式で1行ずつ書いたファイル（例: 21BD1.txt）を置く。
+// パスワードのハッシュ全体ではなく先頭5桁のファイルだけを読むため、k-匿名性を保ったまま
+// 外部のミラーや共有ストレージに置き換えられる。
+type PrefixFileChecker struct {
+	Dir string
+}
+
+func NewPrefixFileChecker(dir string) *PrefixFileChecker {
+	return &PrefixFileChecker{Dir: dir}
+}
+
+func (p *PrefixFileChecker) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+
+	f, err := os.Open(filepath.Join(p.Dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		candidate, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		// パディング用に COUNT が 0 の行が含まれることがある
+		if strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,156 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"context"
+	"main/config"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Violation はパスワードが満たさなかった要件。フィールド単位でクライアントに返す。
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Policy はパスワードの要件
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// 小文字で比較する禁止パスワード
+	Banned map[string]bool
+	// 直近何件のパスワードの再利用を禁止するか（現在のパスワードを含む）
+	HistorySize int
+	// nil の場合は漏洩パスワードの確認をしない
+	Breached BreachedChecker
+}
+
+var Default *Policy
+
+// commonPasswords は設定に関わらず常に禁止するパスワード
+var commonPasswords = []string{
+	"password", "password1", "password123", "passw0rd", "12345678", "123456789", "1234567890",
+	"qwerty123", "qwertyuiop", "iloveyou", "admin123", "letmein", "welcome1", "11111111", "00000000",
+}
+
+// Init は認証設定から Default のポリシーを構築する
+func Init() error {
+	var extra []string
+	if config.Auth.PasswordBannedFile != "" {
+		banned, err := LoadBannedFile(config.Auth.PasswordBannedFile)
+		if err != nil {
+			return err
+		}
+		extra = banned
+	}
+
+	Default = &Policy{
+		MinLength:     config.Auth.PasswordMinLength,
+		RequireUpper:  config.Auth.PasswordRequireUpper,
+		RequireLower:  config.Auth.PasswordRequireLower,
+		RequireDigit:  config.Auth.PasswordRequireDigit,
+		RequireSymbol: config.Auth.PasswordRequireSymbol,
+		Banned:        NewBannedSet(extra),
+		HistorySize:   config.Auth.PasswordHistorySize,
+	}
+	if config.Auth.PasswordBreachedDir != "" {
+		Default.Breached = NewPrefixFileChecker(config.Auth.PasswordBreachedDir)
+	}
+	return nil
+}
+
+// LoadBannedFile は1行に1つ書かれた禁止パスワードを読み込む
+func LoadBannedFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var banned []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			banned = append(banned, line)
+		}
+	}
+	return banned, scanner.Err()
+}
+
+// NewBannedSet は組み込みの一覧と追加の一覧から禁止パスワードの集合を作る
+func NewBannedSet(extra []string) map[string]bool {
+	banned := make(map[string]bool, len(commonPasswords)+len(extra))
+	for _, p := range commonPasswords {
+		banned[p] = true
+	}
+	for _, p := range extra {
+		banned[strings.ToLower(p)] = true
+	}
+	return banned
+}
+
+// Validate はパスワードの形式と漏洩有無を検証し、満たさなかった要件を全て返す。
+// email はパスワードにメールアドレスのローカル部が含まれていないかの確認に使う。
+func (p *Policy) Validate(ctx context.Context, field, password, email string) ([]Violation, error) {
+	var violations []Violation
+	add := func(code, message string) {
+		violations = append(violations, Violation{Field: field, Code: code, Message: message})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		add("too_short", "パスワードは"+strconv.Itoa(p.MinLength)+"文字以上にしてください")
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		add("missing_upper", "英大文字を含めてください")
+	}
+	if p.RequireLower && !lower {
+		add("missing_lower", "英小文字を含めてください")
+	}
+	if p.RequireDigit && !digit {
+		add("missing_digit", "数字を含めてください")
+	}
+	if p.RequireSymbol && !symbol {
+		add("missing_symbol", "記号を含めてください")
+	}
+
+	lowered := strings.ToLower(password)
+	if p.Banned[lowered] {
+		add("banned", "推測されやすいパスワードは使用できません")
+	} else if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok && len(local) >= 3 && strings.Contains(lowered, local) {
+		add("contains_email", "メールアドレスを含むパスワードは使用できません")
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.Breached(ctx, password)
+		if err != nil {
+			return nil, err
+		}
+		if breached {
+			add("breached", "このパスワードは過去に漏洩したことが確認されています。別のパスワードを設定してください")
+		}
+	}
+
+	return violations, nil
+}
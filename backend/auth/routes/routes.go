@@ -3,20 +3,59 @@ package routes
 import (
 	"main/controllers"
 	"main/middlewares"
+	"main/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func InitializeRoutes(r *gin.Engine) {
+	r.GET("/.well-known/jwks.json", controllers.JWKS)
+
 	authRoutes := r.Group("/auth")
 	{
 		authRoutes.POST("/register", controllers.Register)
 		authRoutes.POST("/login", controllers.Login)
+		authRoutes.POST("/login/mfa", controllers.LoginMFA)
+		authRoutes.POST("/refresh", controllers.Refresh)
+		authRoutes.POST("/logout", controllers.Logout)
+		authRoutes.POST("/verify-email", controllers.VerifyEmail)
+		authRoutes.POST("/verify-email/resend", controllers.ResendVerificationEmail)
+		authRoutes.POST("/password/forgot", controllers.ForgotPassword)
+		authRoutes.POST("/password/reset", controllers.ResetPassword)
+		authRoutes.POST("/email/confirm", controllers.ConfirmEmailChange)
+		authRoutes.GET("/oidc/:provider/login", controllers.OIDCLogin)
+		authRoutes.GET("/oidc/:provider/callback", controllers.OIDCCallback)
 	}
 
 	userRoutes := r.Group("/user")
 	userRoutes.Use(middlewares.AuthMiddleware())
 	{
 		userRoutes.GET("/", controllers.GetUser)
+		userRoutes.DELETE("/", controllers.DeleteAccount)
+		userRoutes.POST("/password", controllers.ChangePassword)
+		userRoutes.POST("/email", controllers.ChangeEmail)
+		userRoutes.POST("/mfa/totp/enroll", controllers.EnrollTOTP)
+		userRoutes.POST("/mfa/totp/confirm", controllers.ConfirmTOTP)
+		userRoutes.POST("/mfa/totp/disable", controllers.DisableTOTP)
+	}
+
+	adminRoutes := r.Group("/admin")
+	adminRoutes.Use(middlewares.AuthMiddleware(), middlewares.RequirePermission(models.PermissionUserAdmin))
+	{
+		adminRoutes.POST("/unlock", controllers.UnlockLogin)
+
+		adminRoutes.GET("/permissions", controllers.ListPermissions)
+		adminRoutes.GET("/roles", controllers.ListRoles)
+		adminRoutes.POST("/roles", controllers.CreateRole)
+		adminRoutes.PUT("/roles/:id", controllers.UpdateRole)
+		adminRoutes.DELETE("/roles/:id", controllers.DeleteRole)
+		adminRoutes.GET("/users", controllers.ListUsers)
+		adminRoutes.GET("/users/:id", controllers.GetUserByID)
+		adminRoutes.POST("/users/:id/disable", controllers.DisableUser)
+		adminRoutes.POST("/users/:id/enable", controllers.EnableUser)
+		adminRoutes.POST("/users/:id/password-reset", controllers.ForceUserPasswordReset)
+		adminRoutes.DELETE("/users/:id", controllers.DeleteUser)
+		adminRoutes.POST("/users/:id/roles", controllers.AssignUserRole)
+		adminRoutes.DELETE("/users/:id/roles/:role", controllers.RemoveUserRole)
 	}
 }
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// SigningKey は kid で識別される署名鍵。
+// 秘密鍵を持たない鍵はローテーション後の検証専用として扱う。
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// Keyring は署名に使う鍵と検証に使える鍵の一覧を保持する
+type Keyring struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// JWK は JWKS で公開する公開鍵の表現
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+var Keys *Keyring
+
+// InitKeyring はディレクトリ内のPEMファイルから鍵を読み込む。
+// ファイル名（拡張子 .pem を除く）が kid になり、activeKID の鍵で署名する。
+// ディレクトリが指定されない場合は開発用の一時的な Ed25519 鍵を生成する。
+func InitKeyring(dir, activeKID string) error {
+	if dir == "" {
+		key, err := generateEphemeralKey()
+		if err != nil {
+			return err
+		}
+		Keys = &Keyring{active: key, keys: map[string]*SigningKey{key.ID: key}}
+		return nil
+	}
+
+	keyring, err := LoadKeyring(dir, activeKID)
+	if err != nil {
+		return err
+	}
+	Keys = keyring
+	return nil
+}
+
+// LoadKeyring はディレクトリから Keyring を構築する
+func LoadKeyring(dir, activeKID string) (*Keyring, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*SigningKey)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := parseSigningKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys[kid] = key
+	}
+
+	active, ok := keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found in %s", activeKID, dir)
+	}
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeKID)
+	}
+
+	return &Keyring{active: active, keys: keys}, nil
+}
+
+// Active は署名に使う鍵を返す
+func (k *Keyring) Active() *SigningKey {
+	return k.active
+}
+
+// Lookup は kid に対応する検証用の鍵を返す
+func (k *Keyring) Lookup(kid string) (*SigningKey, bool) {
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// JWKS は全ての検証用公開鍵を kid 順に返す
+func (k *Keyring) JWKS() []JWK {
+	jwks := make([]JWK, 0, len(k.keys))
+	for _, key := range k.keys {
+		jwks = append(jwks, key.JWK())
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+// JWK は公開鍵を JWK 形式に変換する
+func (s *SigningKey) JWK() JWK {
+	switch pub := s.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: s.ID,
+			Use: "sig",
+			Alg: s.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: s.ID,
+			Use: "sig",
+			Alg: s.Method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}
+	}
+	return JWK{Kid: s.ID}
+}
+
+func parseSigningKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: key, PublicKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PublicKey: key}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: key, PublicKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, PublicKey: key}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", parsed)
+}
+
+func generateEphemeralKey() (*SigningKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{ID: "ephemeral-" + kid[:8], Method: jwt.SigningMethodEdDSA, PrivateKey: priv, PublicKey: pub}, nil
+}
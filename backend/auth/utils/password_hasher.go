@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownPasswordHash は保存されたハッシュの形式を判別できないことを示す
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher はパスワードハッシュのアルゴリズムごとの実装。
+// ハッシュ文字列にアルゴリズムとパラメータを含めるため、設定を変えても既存のハッシュを検証できる。
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify はこの実装の形式のハッシュとパスワードを照合する
+	Verify(hash, password string) (bool, error)
+	// Recognizes はハッシュ文字列がこの実装の形式か判定する
+	Recognizes(hash string) bool
+	// NeedsRehash はハッシュのパラメータが現在の設定より弱いか判定する
+	NeedsRehash(hash string) bool
+}
+
+// Passwords は新しいパスワードのハッシュ化に使う実装と、検証に使える実装の一覧
+type Passwords struct {
+	current PasswordHasher
+	known   []PasswordHasher
+	// dummy は存在しないユーザーのログインでも照合の時間を揃えるためのハッシュ
+	dummy string
+}
+
+var PasswordHashers *Passwords
+
+// InitPasswordHashers は algorithm の実装で新しいハッシュを作り、既知の全ての形式を検証できるようにする
+func InitPasswordHashers(algorithm string, argon Argon2idHasher, bcryptCost int) error {
+	bcryptHasher := &BcryptHasher{Cost: bcryptCost}
+	argonHasher := &argon
+
+	var current PasswordHasher
+	switch algorithm {
+	case "argon2id":
+		current = argonHasher
+	case "bcrypt":
+		current = bcryptHasher
+	default:
+		return fmt.Errorf("unsupported password hash algorithm %q", algorithm)
+	}
+
+	dummy, err := current.Hash("dummy password for timing equalization")
+	if err != nil {
+		return fmt.Errorf("failed to create dummy password hash: %v", err)
+	}
+
+	PasswordHashers = &Passwords{current: current, known: []PasswordHasher{argonHasher, bcryptHasher}, dummy: dummy}
+	return nil
+}
+
+// HashPassword は現在のアルゴリズムでパスワードをハッシュ化する
+func HashPassword(password string) (string, error) {
+	return PasswordHashers.current.Hash(password)
+}
+
+// VerifyPassword はハッシュの形式に応じた実装でパスワードを照合する。
+// 一致した場合、現在のアルゴリズムやパラメータで作り直すべきかも返す。
+func VerifyPassword(hash, password string) (ok bool, needsRehash bool, err error) {
+	for _, hasher := range PasswordHashers.known {
+		if !hasher.Recognizes(hash) {
+			continue
+		}
+
+		ok, err := hasher.Verify(hash, password)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, hasher != PasswordHashers.current || hasher.NeedsRehash(hash), nil
+	}
+	return false, false, ErrUnknownPasswordHash
+}
+
+// VerifyDummyPassword は存在しないユーザーのログインで、実在するユーザーと同じ時間をかけて
+// ダミーのハッシュと照合する。結果は常に不一致として扱う。
+func VerifyDummyPassword(password string) {
+	_, _ = PasswordHashers.current.Verify(PasswordHashers.dummy, password)
+}
+
+// BcryptHasher は bcrypt によるハッシュ。移行前のハッシュの検証に使う。
+type BcryptHasher struct {
+	Cost int
+}
+
+func (b *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b *BcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (b *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < b.Cost
+}
+
+// Argon2idHasher は argon2id によるハッシュ。
+// PHC 形式 $argon2id$v=19$m=<KiB>,t=<回数>,p=<並列度>$<salt>$<hash> で保存する。
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (a *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2idHasher) Verify(hash, password string) (bool, error) {
+	params, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+func (a *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (a *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	return params.memory < a.Memory ||
+		params.iterations < a.Iterations ||
+		params.parallelism < a.Parallelism ||
+		uint32(len(params.salt)) < a.SaltLength ||
+		uint32(len(params.key)) < a.KeyLength
+}
+
+func parseArgon2idHash(hash string) (*argon2idParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+
+	params := &argon2idParams{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("invalid argon2 key: %w", err)
+	}
+	return params, nil
+}
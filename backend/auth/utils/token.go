@@ -1,39 +1,158 @@
 package utils
 
 import (
-	"os"
+	"errors"
+	"fmt"
+	"main/config"
 	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_SECRET"))
+// トークン検証の失敗理由。ミドルウェアはこれらを errors.Is で判定する。
+var (
+	ErrTokenMalformed   = errors.New("token is malformed")
+	ErrTokenSignature   = errors.New("token signature is invalid")
+	ErrTokenExpired     = errors.New("token is expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrTokenIssuer      = errors.New("token issuer is invalid")
+	ErrTokenAudience    = errors.New("token audience is invalid")
+)
+
+// Claims はアクセストークンに含めるクレーム
+type Claims struct {
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.StandardClaims
+}
+
+// HasPermission はトークンが指定の権限を持つか判定する
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UserID は sub クレームからユーザーIDを取り出す
+func (c *Claims) UserID() (uint, error) {
+	userID, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid subject", ErrTokenMalformed)
+	}
+	return uint(userID), nil
+}
+
+// validate は許容する時刻のずれを考慮して標準クレームを検証する
+func (c *Claims) validate(now time.Time, audience string) error {
+	skew := config.Auth.JWTClockSkew
+
+	if c.ExpiresAt == 0 || now.Add(-skew).Unix() > c.ExpiresAt {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(skew).Unix() < c.NotBefore {
+		return ErrTokenNotYetValid
+	}
+	if c.IssuedAt != 0 && now.Add(skew).Unix() < c.IssuedAt {
+		return ErrTokenNotYetValid
+	}
+	if c.Issuer != config.Auth.JWTIssuer {
+		return ErrTokenIssuer
+	}
+	if c.Audience != audience {
+		return ErrTokenAudience
+	}
+	if _, err := c.UserID(); err != nil {
+		return err
+	}
+	return nil
+}
 
-func GenerateToken(userID uint) (string, error) {
-	claims := jwt.MapClaims{}
-	claims["authorized"] = true
-	claims["user_id"] = strconv.FormatUint(uint64(userID), 10)
-	claims["exp"] = time.Now().Add(time.Hour * 72).Unix()
+// mfaPendingAudience は二段階認証待ちトークンの aud。
+// 通常のアクセストークンとは aud が異なるため AuthMiddleware では受け付けられない。
+const mfaPendingAudience = "mfa-pending"
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// GenerateToken はロールと権限を含むアクセストークンを発行する
+func GenerateToken(userID uint, roles, permissions []string) (string, error) {
+	return generateToken(userID, roles, permissions, config.Auth.JWTAudience, config.Auth.AccessTokenTTL)
+}
 
-	return token.SignedString(jwtKey)
+// GenerateMFAPendingToken はパスワード認証済みで二段階認証待ちであることを示す短命のトークンを発行する
+func GenerateMFAPendingToken(userID uint) (string, error) {
+	return generateToken(userID, nil, nil, mfaPendingAudience, config.Auth.MFAPendingTTL)
 }
 
-func ParseToken(tokenStr string) (uint, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+func generateToken(userID uint, roles, permissions []string, audience string, ttl time.Duration) (string, error) {
+	jti, _, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		Roles:       roles,
+		Permissions: permissions,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Issuer:    config.Auth.JWTIssuer,
+			Audience:  audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+			Id:        jti,
+		},
+	}
+
+	key := Keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userIDStr := claims["user_id"].(string)
-		userID, err := strconv.ParseUint(userIDStr, 10, 32)
-		if err != nil {
-			return 0, err
+	return token.SignedString(key.PrivateKey)
+}
+
+// verificationKey は kid ヘッダーから検証鍵を選び、alg が鍵と一致することを確認する
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := Keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+	}
+	return key.PublicKey, nil
+}
+
+// ParseToken は署名とクレームを検証し、型付きのクレームを返す
+func ParseToken(tokenStr string) (*Claims, error) {
+	return parseToken(tokenStr, config.Auth.JWTAudience)
+}
+
+// ParseMFAPendingToken は二段階認証待ちトークンを検証する
+func ParseMFAPendingToken(tokenStr string) (*Claims, error) {
+	return parseToken(tokenStr, mfaPendingAudience)
+}
+
+func parseToken(tokenStr, audience string) (*Claims, error) {
+	parser := jwt.Parser{
+		ValidMethods:         config.Auth.JWTAllowedAlgs,
+		SkipClaimsValidation: true,
+	}
+
+	claims := &Claims{}
+	if _, err := parser.ParseWithClaims(tokenStr, claims, verificationKey); err != nil {
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Errors&jwt.ValidationErrorMalformed != 0 {
+			return nil, fmt.Errorf("%w: %v", ErrTokenMalformed, err)
 		}
-		return uint(userID), nil
-	} else {
-		return 0, err
+		return nil, fmt.Errorf("%w: %v", ErrTokenSignature, err)
+	}
+
+	if err := claims.validate(time.Now(), audience); err != nil {
+		return nil, err
 	}
+	return claims, nil
 }
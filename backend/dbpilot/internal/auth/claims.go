@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 権限名は認証サービスの models.Permission* と揃える
+const (
+	PermissionIncidentRead   = "incident:read"
+	PermissionIncidentWrite  = "incident:write"
+	PermissionIncidentDelete = "incident:delete"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+// Claims は認証サービスが発行するアクセストークンのクレーム
+type Claims struct {
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID は sub クレームからユーザーIDを取り出す
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// HasPermission はトークンが指定の権限を持つか判定する
+func (c *Claims) HasPermission(permission string) bool {
+	for _, p := range c.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type claimsKey struct{}
+
+// WithClaims はクレームをコンテキストに格納する
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext はコンテキストからクレームを取り出す
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// RequirePermission はコンテキストのクレームが権限を持たなければエラーを返す
+func RequirePermission(ctx context.Context, permission string) error {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !claims.HasPermission(permission) {
+		return ErrForbidden
+	}
+	return nil
+}
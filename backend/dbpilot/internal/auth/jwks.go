@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 未知の kid を受け取ったときに JWKS を再取得する最短間隔
+const jwksMinRefreshInterval = time.Minute
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// verificationKey は JWKS から取り出した検証用公開鍵
+type verificationKey struct {
+	alg string
+	key interface{}
+}
+
+// JWKSCache は認証サービスの JWKS を取得してキャッシュする。
+// 鍵のローテーションに対応するため、未知の kid を受け取ると再取得する。
+type JWKSCache struct {
+	url    string
+	client *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]verificationKey
+	lastFetched time.Time
+}
+
+func NewJWKSCache(url string) *JWKSCache {
+	return &JWKSCache{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   make(map[string]verificationKey),
+	}
+}
+
+// Key は kid に対応する公開鍵と alg を返す
+func (c *JWKSCache) Key(ctx context.Context, kid string) (interface{}, string, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	stale := time.Since(c.lastFetched) >= jwksMinRefreshInterval
+	c.mu.RUnlock()
+
+	if ok {
+		return key.key, key.alg, nil
+	}
+	if !stale {
+		return nil, "", fmt.Errorf("unknown key id %q", kid)
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if key, ok := c.keys[kid]; ok {
+		return key.key, key.alg, nil
+	}
+	return nil, "", fmt.Errorf("unknown key id %q", kid)
+}
+
+func (c *JWKSCache) refresh(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 他のリクエストが直前に更新していれば取得しない
+	if time.Since(c.lastFetched) < jwksMinRefreshInterval {
+		return nil
+	}
+	c.lastFetched = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %v", err)
+	}
+
+	keys := make(map[string]verificationKey, len(body.Keys))
+	for _, k := range body.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			return fmt.Errorf("invalid JWK %q: %v", k.Kid, err)
+		}
+		keys[k.Kid] = verificationKey{alg: k.Alg, key: key}
+	}
+	c.keys = keys
+	return nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware は Bearer トークンを検証し、クレームをリクエストのコンテキストに格納する。
+// トークンがない場合は匿名のまま通し、権限の判定は @hasPermission ディレクティブで行う。
+func Middleware(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			return
+		}
+
+		claims, err := v.Verify(c.Request.Context(), tokenString)
+		if err != nil {
+			log.Printf("Invalid access token: %v", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithClaims(c.Request.Context(), claims))
+		c.Next()
+	}
+}
+
+// HasPermissionDirective は @hasPermission(permission:) ディレクティブの実装
+func HasPermissionDirective(ctx context.Context, obj interface{}, next graphql.Resolver, permission string) (interface{}, error) {
+	if err := RequirePermission(ctx, permission); err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
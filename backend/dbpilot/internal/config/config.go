@@ -15,6 +15,12 @@ type Config struct {
 	DBName     string
 	DBSSLMode  string
 	ServerPort string
+
+	AuthJWKSURL string
+	JWTIssuer   string
+	JWTAudience string
+
+	IncidentWorkflow string
 }
 
 func LoadConfig() (*Config, error) {
@@ -31,6 +37,12 @@ func LoadConfig() (*Config, error) {
 		DBName:     os.Getenv("DB_NAME"),
 		DBSSLMode:  os.Getenv("DB_SSLMODE"),
 		ServerPort: os.Getenv("SERVER_PORT"),
+
+		AuthJWKSURL: getEnv("AUTH_JWKS_URL", "http://localhost:8080/.well-known/jwks.json"),
+		JWTIssuer:   getEnv("JWT_ISSUER", "auth-service"),
+		JWTAudience: getEnv("JWT_AUDIENCE", "incident-api"),
+
+		IncidentWorkflow: os.Getenv("INCIDENT_WORKFLOW"),
 	}, nil
 }
 
@@ -40,3 +52,10 @@ func (c *Config) GetDBConnString() string {
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName, c.DBSSLMode,
 	)
 }
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"dbpilot/internal/models"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// incidentEnumColumn は列挙型に移行するカラムと、既存の値の変換方法。
+// 変換できない値は Fallback に置き換える。
+type incidentEnumColumn struct {
+	Column     string
+	Constraint string
+	Allowed    []string
+	Parse      func(string) (string, bool)
+	Fallback   string
+}
+
+var incidentEnumColumns = []incidentEnumColumn{
+	{
+		Column:     "status",
+		Constraint: "chk_incidents_status",
+		Allowed:    enumValues(models.AllIncidentStatuses),
+		Parse: func(s string) (string, bool) {
+			v, ok := models.ParseIncidentStatus(s)
+			return string(v), ok
+		},
+		Fallback: string(models.IncidentStatusNew),
+	},
+	{
+		Column:     "priority",
+		Constraint: "chk_incidents_priority",
+		Allowed:    enumValues(models.AllIncidentPriorities),
+		Parse: func(s string) (string, bool) {
+			v, ok := models.ParseIncidentPriority(s)
+			return string(v), ok
+		},
+		Fallback: string(models.IncidentPriorityMedium),
+	},
+	{
+		Column:     "judgment",
+		Constraint: "chk_incidents_judgment",
+		Allowed:    enumValues(models.AllIncidentJudgments),
+		Parse: func(s string) (string, bool) {
+			v, ok := models.ParseIncidentJudgment(s)
+			return string(v), ok
+		},
+		Fallback: string(models.IncidentJudgmentActionRequired),
+	},
+}
+
+func enumValues[T ~string](values []T) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = string(v)
+	}
+	return out
+}
+
+// unmappedIncidentValue は列挙値に変換できず、既定値に置き換えた値
+type unmappedIncidentValue struct {
+	Column string
+	Value  string
+	IDs    []uint
+}
+
+// constrainIncidentEnums は既存の値を列挙値に変換してから CHECK 制約を追加する。
+// 変換できない値は該当するインシデントを報告したうえで列ごとの既定値に置き換える。
+func constrainIncidentEnums(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		unmapped, err := mapIncidentEnumValues(tx)
+		if err != nil {
+			return err
+		}
+		if len(unmapped) > 0 {
+			log.Printf("Replaced %d unmapped incident values with fallbacks; review the incidents listed above", len(unmapped))
+		}
+
+		for _, col := range incidentEnumColumns {
+			if err := tx.Exec(fmt.Sprintf(
+				"ALTER TABLE incidents ADD CONSTRAINT %s CHECK (%s IN ('%s'))",
+				col.Constraint, col.Column, strings.Join(col.Allowed, "', '"),
+			)).Error; err != nil {
+				return fmt.Errorf("failed to add %s: %v", col.Constraint, err)
+			}
+		}
+		return nil
+	})
+}
+
+// mapIncidentEnumValues は既存の値を列挙値に変換し、既定値に置き換えた値を返す
+func mapIncidentEnumValues(tx *gorm.DB) ([]unmappedIncidentValue, error) {
+	var unmapped []unmappedIncidentValue
+
+	for _, col := range incidentEnumColumns {
+		var values []string
+		if err := tx.Table("incidents").Distinct(col.Column).Pluck(col.Column, &values).Error; err != nil {
+			return nil, fmt.Errorf("failed to read incident %s values: %v", col.Column, err)
+		}
+
+		for _, value := range values {
+			mapped, ok := col.Parse(value)
+			if ok && mapped == value {
+				continue
+			}
+
+			var ids []uint
+			if err := tx.Table("incidents").
+				Where(col.Column+" = ?", value).
+				Order("id").
+				Pluck("id", &ids).Error; err != nil {
+				return nil, fmt.Errorf("failed to read incidents with %s %q: %v", col.Column, value, err)
+			}
+			if !ok {
+				mapped = col.Fallback
+				log.Printf("Cannot map incident %s %q; using %q for incidents %v", col.Column, value, mapped, ids)
+				unmapped = append(unmapped, unmappedIncidentValue{Column: col.Column, Value: value, IDs: ids})
+			}
+
+			if err := tx.Table("incidents").
+				Where(col.Column+" = ?", value).
+				Update(col.Column, mapped).Error; err != nil {
+				return nil, fmt.Errorf("failed to map incident %s %q: %v", col.Column, value, err)
+			}
+			if ok {
+				log.Printf("Mapped incident %s %q to %q (%d rows)", col.Column, value, mapped, len(ids))
+			}
+		}
+	}
+	return unmapped, nil
+}
+
+// dropIncidentEnumConstraints は constrainIncidentEnums で追加した CHECK 制約を削除する。
+// 変換済みの値は元に戻さない。
+func dropIncidentEnumConstraints(db *gorm.DB) error {
+	for _, col := range incidentEnumColumns {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE incidents DROP CONSTRAINT IF EXISTS %s", col.Constraint)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
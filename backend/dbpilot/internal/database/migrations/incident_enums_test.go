@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"dbpilot/internal/models"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMapIncidentEnumValues(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "dbpilot.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Incident{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	// 列挙型の導入前に保存されていた値
+	legacy := []struct{ status, priority, judgment string }{
+		{"open", "High", "要対応"},
+		{"調査中", "high", "watch"},
+		{"pending", "P1", "monitor"},
+		{"closed", "P9", "???"},
+		{"pending", "中", "action_required"},
+	}
+	for _, v := range legacy {
+		incident := models.Incident{
+			DateTime:  time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC),
+			Status:    models.IncidentStatus(v.status),
+			Priority:  models.IncidentPriority(v.priority),
+			Judgment:  models.IncidentJudgment(v.judgment),
+			Content:   "content",
+			Assignee:  "yamada",
+			FromEmail: "alert@example.com",
+			ToEmail:   "ops@example.com",
+			Subject:   "subject",
+		}
+		if err := db.Create(&incident).Error; err != nil {
+			t.Fatalf("failed to create incident: %v", err)
+		}
+	}
+
+	unmapped, err := mapIncidentEnumValues(db)
+	if err != nil {
+		t.Fatalf("mapIncidentEnumValues: %v", err)
+	}
+
+	wantUnmapped := []unmappedIncidentValue{
+		{Column: "status", Value: "pending", IDs: []uint{3, 5}},
+		{Column: "priority", Value: "P9", IDs: []uint{4}},
+		{Column: "judgment", Value: "???", IDs: []uint{4}},
+	}
+	if !reflect.DeepEqual(unmapped, wantUnmapped) {
+		t.Errorf("unmapped = %+v, want %+v", unmapped, wantUnmapped)
+	}
+
+	var incidents []models.Incident
+	if err := db.Order("id").Find(&incidents).Error; err != nil {
+		t.Fatalf("failed to read incidents: %v", err)
+	}
+	want := []struct {
+		status   models.IncidentStatus
+		priority models.IncidentPriority
+		judgment models.IncidentJudgment
+	}{
+		{models.IncidentStatusNew, models.IncidentPriorityHigh, models.IncidentJudgmentActionRequired},
+		{models.IncidentStatusInProgress, models.IncidentPriorityHigh, models.IncidentJudgmentMonitor},
+		{models.IncidentStatusNew, models.IncidentPriorityHigh, models.IncidentJudgmentMonitor},
+		{models.IncidentStatusClosed, models.IncidentPriorityMedium, models.IncidentJudgmentActionRequired},
+		{models.IncidentStatusNew, models.IncidentPriorityMedium, models.IncidentJudgmentActionRequired},
+	}
+	for i, incident := range incidents {
+		if incident.Status != want[i].status || incident.Priority != want[i].priority || incident.Judgment != want[i].judgment {
+			t.Errorf("incident %d = %s/%s/%s, want %s/%s/%s", incident.ID,
+				incident.Status, incident.Priority, incident.Judgment,
+				want[i].status, want[i].priority, want[i].judgment)
+		}
+	}
+
+	// 変換後にもう一度実行しても何も変わらない
+	if unmapped, err := mapIncidentEnumValues(db); err != nil || len(unmapped) != 0 {
+		t.Errorf("second run: unmapped = %+v, err = %v, want none", unmapped, err)
+	}
+}
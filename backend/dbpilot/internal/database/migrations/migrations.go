@@ -93,6 +93,35 @@ func RunMigrations(db *gorm.DB) error {
 				return db.Migrator().DropTable(&models.IncidentRelation{})
 			},
 		},
+		{
+			Name: "add_incident_list_indexes",
+			Migrate: func(db *gorm.DB) error {
+				// カーソル方式のページ分割と絞り込みに使うインデックス
+				return db.Exec(`
+                    CREATE INDEX IF NOT EXISTS idx_incidents_date_time_id ON incidents (date_time, id);
+                    CREATE INDEX IF NOT EXISTS idx_incidents_created_at_id ON incidents (created_at, id);
+                    CREATE INDEX IF NOT EXISTS idx_incidents_updated_at_id ON incidents (updated_at, id);
+                    CREATE INDEX IF NOT EXISTS idx_incidents_status ON incidents (status);
+                    CREATE INDEX IF NOT EXISTS idx_incidents_priority ON incidents (priority);
+                    CREATE INDEX IF NOT EXISTS idx_incidents_assignee ON incidents (assignee);
+                `).Error
+			},
+			Rollback: dropIncidentListIndexes,
+		},
+		{
+			Name:     "constrain_incident_enums",
+			Migrate:  constrainIncidentEnums,
+			Rollback: dropIncidentEnumConstraints,
+		},
+		{
+			Name: "create_incident_status_histories_table",
+			Migrate: func(db *gorm.DB) error {
+				return db.AutoMigrate(&models.IncidentStatusHistory{})
+			},
+			Rollback: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.IncidentStatusHistory{})
+			},
+		},
 	}
 
 	// マイグレーションの実行
@@ -140,6 +169,20 @@ func RollbackLastMigration(db *gorm.DB) error {
 		Name     string
 		Rollback func(*gorm.DB) error
 	}{
+		{
+			Name: "create_incident_status_histories_table",
+			Rollback: func(db *gorm.DB) error {
+				return db.Migrator().DropTable(&models.IncidentStatusHistory{})
+			},
+		},
+		{
+			Name:     "constrain_incident_enums",
+			Rollback: dropIncidentEnumConstraints,
+		},
+		{
+			Name:     "add_incident_list_indexes",
+			Rollback: dropIncidentListIndexes,
+		},
 		{
 			Name: "create_incident_relations_table",
 			Rollback: func(db *gorm.DB) error {
@@ -183,3 +226,15 @@ func RollbackLastMigration(db *gorm.DB) error {
 
 	return fmt.Errorf("migration '%s' not found in rollback definitions", lastMigration.Name)
 }
+
+// dropIncidentListIndexes は add_incident_list_indexes で作成したインデックスを削除する
+func dropIncidentListIndexes(db *gorm.DB) error {
+	return db.Exec(`
+        DROP INDEX IF EXISTS idx_incidents_date_time_id;
+        DROP INDEX IF EXISTS idx_incidents_created_at_id;
+        DROP INDEX IF EXISTS idx_incidents_updated_at_id;
+        DROP INDEX IF EXISTS idx_incidents_status;
+        DROP INDEX IF EXISTS idx_incidents_priority;
+        DROP INDEX IF EXISTS idx_incidents_assignee;
+    `).Error
+}
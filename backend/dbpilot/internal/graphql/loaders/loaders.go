@@ -0,0 +1,144 @@
+package loaders
+
+import (
+	"context"
+	"dbpilot/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/dataloader/v7"
+	"gorm.io/gorm"
+)
+
+// batchWait は同じリクエスト内のキーを1回のクエリにまとめるまでの待ち時間。
+// gqlgen は一覧の各要素のフィールドを別々の goroutine で解決するため、
+// 短すぎると 100 件程度の一覧でもバッチが分割される。
+const batchWait = 16 * time.Millisecond
+
+// Loaders はリクエスト単位で作成するデータローダーの集まり。
+// インシデントIDごとの取得を IncidentID IN (...) の1クエリにまとめる。
+type Loaders struct {
+	ResponsesByIncident     *dataloader.Loader[uint, []*models.Response]
+	RelatedToIncidents      *dataloader.Loader[uint, []*models.IncidentRelation]
+	RelatedFromIncidents    *dataloader.Loader[uint, []*models.IncidentRelation]
+	StatusHistoryByIncident *dataloader.Loader[uint, []*models.IncidentStatusHistory]
+}
+
+// New は新しいデータローダーを作成する。キャッシュはリクエストの間だけ有効にするため、
+// リクエストごとに作り直すこと。
+func New(db *gorm.DB) *Loaders {
+	return &Loaders{
+		ResponsesByIncident: dataloader.NewBatchedLoader(
+			responsesByIncident(db), dataloader.WithWait[uint, []*models.Response](batchWait)),
+		RelatedToIncidents: dataloader.NewBatchedLoader(
+			relationsBy(db, "incident_id"), dataloader.WithWait[uint, []*models.IncidentRelation](batchWait)),
+		RelatedFromIncidents: dataloader.NewBatchedLoader(
+			relationsBy(db, "related_incident_id"), dataloader.WithWait[uint, []*models.IncidentRelation](batchWait)),
+		StatusHistoryByIncident: dataloader.NewBatchedLoader(
+			statusHistoryByIncident(db), dataloader.WithWait[uint, []*models.IncidentStatusHistory](batchWait)),
+	}
+}
+
+type loadersKey struct{}
+
+// Middleware はリクエストごとにデータローダーを作成し、コンテキストに格納する
+func Middleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), loadersKey{}, New(db))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// For はコンテキストからデータローダーを取り出す
+func For(ctx context.Context) (*Loaders, bool) {
+	l, ok := ctx.Value(loadersKey{}).(*Loaders)
+	return l, ok
+}
+
+func responsesByIncident(db *gorm.DB) dataloader.BatchFunc[uint, []*models.Response] {
+	return func(ctx context.Context, incidentIDs []uint) []*dataloader.Result[[]*models.Response] {
+		var responses []*models.Response
+		err := db.WithContext(ctx).
+			Where("incident_id IN ?", incidentIDs).
+			Order("date_time, id").
+			Find(&responses).Error
+		if err != nil {
+			return errorResults[[]*models.Response](len(incidentIDs), fmt.Errorf("failed to fetch responses: %v", err))
+		}
+
+		grouped := make(map[uint][]*models.Response, len(incidentIDs))
+		for _, response := range responses {
+			grouped[response.IncidentID] = append(grouped[response.IncidentID], response)
+		}
+		return groupedResults(incidentIDs, grouped)
+	}
+}
+
+func statusHistoryByIncident(db *gorm.DB) dataloader.BatchFunc[uint, []*models.IncidentStatusHistory] {
+	return func(ctx context.Context, incidentIDs []uint) []*dataloader.Result[[]*models.IncidentStatusHistory] {
+		var histories []*models.IncidentStatusHistory
+		err := db.WithContext(ctx).
+			Where("incident_id IN ?", incidentIDs).
+			Order("created_at, id").
+			Find(&histories).Error
+		if err != nil {
+			return errorResults[[]*models.IncidentStatusHistory](len(incidentIDs), fmt.Errorf("failed to fetch status history: %v", err))
+		}
+
+		grouped := make(map[uint][]*models.IncidentStatusHistory, len(incidentIDs))
+		for _, history := range histories {
+			grouped[history.IncidentID] = append(grouped[history.IncidentID], history)
+		}
+		return groupedResults(incidentIDs, grouped)
+	}
+}
+
+// relationsBy は column（incident_id または related_incident_id）でまとめて関連を取得する。
+// 関連先のインシデントも一括で読み込み、インシデントごとの追加クエリを発生させない。
+func relationsBy(db *gorm.DB, column string) dataloader.BatchFunc[uint, []*models.IncidentRelation] {
+	return func(ctx context.Context, incidentIDs []uint) []*dataloader.Result[[]*models.IncidentRelation] {
+		var relations []*models.IncidentRelation
+		err := db.WithContext(ctx).
+			Preload("Incident").
+			Preload("RelatedIncident").
+			Where(column+" IN ?", incidentIDs).
+			Order("id").
+			Find(&relations).Error
+		if err != nil {
+			return errorResults[[]*models.IncidentRelation](len(incidentIDs), fmt.Errorf("failed to fetch incident relations: %v", err))
+		}
+
+		grouped := make(map[uint][]*models.IncidentRelation, len(incidentIDs))
+		for _, relation := range relations {
+			key := relation.IncidentID
+			if column == "related_incident_id" {
+				key = relation.RelatedIncidentID
+			}
+			grouped[key] = append(grouped[key], relation)
+		}
+		return groupedResults(incidentIDs, grouped)
+	}
+}
+
+// groupedResults はキーの順番どおりに結果を並べる。該当のないキーには空のスライスを返す。
+func groupedResults[V any](keys []uint, grouped map[uint][]V) []*dataloader.Result[[]V] {
+	results := make([]*dataloader.Result[[]V], len(keys))
+	for i, key := range keys {
+		values := grouped[key]
+		if values == nil {
+			values = []V{}
+		}
+		results[i] = &dataloader.Result[[]V]{Data: values}
+	}
+	return results
+}
+
+func errorResults[V any](n int, err error) []*dataloader.Result[V] {
+	results := make([]*dataloader.Result[V], n)
+	for i := range results {
+		results[i] = &dataloader.Result[V]{Error: err}
+	}
+	return results
+}
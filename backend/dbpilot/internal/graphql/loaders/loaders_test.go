@@ -0,0 +1,188 @@
+package loaders_test
+
+import (
+	"dbpilot/internal/auth"
+	"dbpilot/internal/graphql/generated"
+	"dbpilot/internal/graphql/loaders"
+	"dbpilot/internal/graphql/resolvers"
+	"dbpilot/internal/models"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const nestedIncidentsQuery = `{
+  incidents {
+    id
+    responses { id }
+    relatedToIncidents { id relatedIncident { id } }
+    relatedFromIncidents { id incident { id } }
+    statusHistory { id }
+  }
+}`
+
+// queryCounter はデータベースに発行された SELECT 文の数を数える
+type queryCounter struct {
+	n atomic.Int64
+}
+
+func (q *queryCounter) register(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	err := db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		q.n.Add(1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "dbpilot.db") + "?_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&models.Incident{},
+		&models.Response{},
+		&models.IncidentRelation{},
+		&models.IncidentStatusHistory{},
+	); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// seedIncidents は対応履歴・関連・状態遷移の履歴を持つインシデントを n 件作成する
+func seedIncidents(t *testing.T, db *gorm.DB, n int) {
+	t.Helper()
+
+	incidents := make([]*models.Incident, n)
+	for i := range incidents {
+		incidents[i] = &models.Incident{
+			DateTime:  time.Date(2024, 4, 1, 9, i, 0, 0, time.UTC),
+			Status:    models.IncidentStatusNew,
+			Judgment:  models.IncidentJudgmentMonitor,
+			Content:   "content",
+			Assignee:  "yamada",
+			Priority:  models.IncidentPriorityLow,
+			FromEmail: "alert@example.com",
+			ToEmail:   "ops@example.com",
+			Subject:   "subject",
+		}
+	}
+	if err := db.Create(&incidents).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for i, incident := range incidents {
+		for j := 0; j < 2; j++ {
+			response := models.Response{IncidentID: incident.ID, DateTime: incident.DateTime, Responder: "yamada", Content: "response"}
+			if err := db.Create(&response).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		history := models.IncidentStatusHistory{IncidentID: incident.ID, ToStatus: incident.Status}
+		if err := db.Create(&history).Error; err != nil {
+			t.Fatal(err)
+		}
+		next := incidents[(i+1)%n]
+		relation := models.IncidentRelation{IncidentID: incident.ID, RelatedIncidentID: next.ID}
+		if err := db.Create(&relation).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestServer は本番と同じくデータローダーのミドルウェアを挟んだ GraphQL エンドポイントを作る
+func newTestServer(db *gorm.DB) *client.Client {
+	gin.SetMode(gin.TestMode)
+
+	config := generated.Config{
+		Resolvers: &resolvers.Resolver{DB: db},
+		Directives: generated.DirectiveRoot{
+			HasPermission: auth.HasPermissionDirective,
+		},
+	}
+	h := handler.NewDefaultServer(generated.NewExecutableSchema(config))
+
+	r := gin.New()
+	r.POST("/query",
+		func(c *gin.Context) {
+			claims := &auth.Claims{Permissions: []string{auth.PermissionIncidentRead}}
+			c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), claims))
+		},
+		loaders.Middleware(db),
+		func(c *gin.Context) { h.ServeHTTP(c.Writer, c.Request) },
+	)
+	return client.New(r, client.Path("/query"))
+}
+
+// nestedQueryCount は n 件のインシデントに対して入れ子のクエリを実行し、発行された SELECT 文の数を返す
+func nestedQueryCount(t *testing.T, n int) int64 {
+	t.Helper()
+
+	db := newTestDB(t)
+	seedIncidents(t, db, n)
+
+	var counter queryCounter
+	counter.register(t, db)
+
+	var resp struct {
+		Incidents []struct {
+			ID                 string
+			Responses          []struct{ ID string }
+			RelatedToIncidents []struct {
+				ID              string
+				RelatedIncident struct{ ID string }
+			}
+			RelatedFromIncidents []struct {
+				ID       string
+				Incident struct{ ID string }
+			}
+			StatusHistory []struct{ ID string }
+		}
+	}
+	newTestServer(db).MustPost(nestedIncidentsQuery, &resp)
+
+	if len(resp.Incidents) != n {
+		t.Fatalf("incidents = %d, want %d", len(resp.Incidents), n)
+	}
+	for _, incident := range resp.Incidents {
+		if len(incident.Responses) != 2 || len(incident.RelatedToIncidents) != 1 ||
+			len(incident.RelatedFromIncidents) != 1 || len(incident.StatusHistory) != 1 {
+			t.Fatalf("incident %s has unexpected nested data: %+v", incident.ID, incident)
+		}
+	}
+	return counter.n.Load()
+}
+
+func TestNestedIncidentsQueryIsBatched(t *testing.T) {
+	// incidents 1 + responses 1 + statusHistory 1
+	// + relatedToIncidents / relatedFromIncidents それぞれ 1（関連） + 2（両端のインシデントの Preload）
+	const want = 9
+
+	for _, n := range []int{1, 10, 100} {
+		if got := nestedQueryCount(t, n); got != want {
+			t.Errorf("%d incidents issued %d queries, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package resolvers
+
+import (
+	"dbpilot/internal/models"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidID         = errors.New("invalid id")
+	ErrNotFound          = errors.New("not found")
+	ErrSelfRelation      = errors.New("an incident cannot be related to itself")
+	ErrDuplicateRelation = errors.New("incident relation already exists")
+)
+
+// parseID は GraphQL の ID をデータベースの主キーに変換する
+func parseID(id string) (uint, error) {
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return uint(n), nil
+}
+
+// formatID は主キーを GraphQL の ID 表現に変換する
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// formatTime は日時を RFC3339 形式の文字列に変換する
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+// parseDateTime は RFC3339 形式の日時を解析する
+func parseDateTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid datetime format: %v", err)
+	}
+	return t, nil
+}
+
+// notFound はレコードが存在しない場合に ErrNotFound をラップしたエラーを返す
+func notFound(err error, kind string, id uint) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%s %d %w", kind, id, ErrNotFound)
+	}
+	return fmt.Errorf("failed to fetch %s: %v", kind, err)
+}
+
+// ensureIncidentExists は参照先のインシデントが存在することを確認する
+func ensureIncidentExists(tx *gorm.DB, id uint) error {
+	var incident models.Incident
+	if err := tx.Select("id").First(&incident, id).Error; err != nil {
+		return notFound(err, "incident", id)
+	}
+	return nil
+}
+
+// isUniqueViolation はエラーが一意制約違反かどうかをドライバーに依存せず判定する
+func isUniqueViolation(db *gorm.DB, err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		return errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package resolvers
+
+import (
+	"dbpilot/internal/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// defaultIncidentOrder は orderBy が指定されない場合の並び順
+var defaultIncidentOrder = models.IncidentOrder{
+	Field:     models.IncidentOrderFieldDatetime,
+	Direction: models.OrderDirectionDesc,
+}
+
+// incidentCursor はカーソルに埋め込む並べ替えキーの値。
+// 同じ値のレコードがあっても順序が一意になるよう ID を併せて持つ。
+type incidentCursor struct {
+	Field models.IncidentOrderField `json:"f"`
+	Value string                    `json:"v,omitempty"`
+	ID    uint                      `json:"id"`
+}
+
+// encodeIncidentCursor はインシデントの位置を不透明なカーソル文字列に変換する
+func encodeIncidentCursor(field models.IncidentOrderField, incident *models.Incident) string {
+	cursor := incidentCursor{Field: field, ID: incident.ID}
+	switch field {
+	case models.IncidentOrderFieldDatetime:
+		cursor.Value = incident.DateTime.UTC().Format(time.RFC3339Nano)
+	case models.IncidentOrderFieldCreatedAt:
+		cursor.Value = incident.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case models.IncidentOrderFieldUpdatedAt:
+		cursor.Value = incident.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeIncidentCursor はカーソルを解析する。別の並び順で発行されたカーソルは受け付けない。
+func decodeIncidentCursor(s string, field models.IncidentOrderField) (*incidentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor incidentCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Field != field {
+		return nil, fmt.Errorf("%w: cursor was issued for a different order", ErrInvalidCursor)
+	}
+	return &cursor, nil
+}
+
+// applyIncidentCursor はカーソルより後ろのレコードに絞り込むキーセット条件を追加する
+func applyIncidentCursor(query *gorm.DB, order models.IncidentOrder, cursor *incidentCursor) (*gorm.DB, error) {
+	op := ">"
+	if order.Direction == models.OrderDirectionDesc {
+		op = "<"
+	}
+
+	if order.Field == models.IncidentOrderFieldID {
+		return query.Where("id "+op+" ?", cursor.ID), nil
+	}
+
+	value, err := time.Parse(time.RFC3339Nano, cursor.Value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	column := order.Field.Column()
+	return query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), value, cursor.ID), nil
+}
+
+// applyIncidentFilter は絞り込み条件をクエリに追加する
+func applyIncidentFilter(query *gorm.DB, filter *models.IncidentFilter) (*gorm.DB, error) {
+	if filter == nil {
+		return query, nil
+	}
+
+	if len(filter.Status) > 0 {
+		query = query.Where("status IN ?", filter.Status)
+	}
+	if len(filter.Priority) > 0 {
+		query = query.Where("priority IN ?", filter.Priority)
+	}
+	if len(filter.Judgment) > 0 {
+		query = query.Where("judgment IN ?", filter.Judgment)
+	}
+	if filter.Assignee != nil {
+		query = query.Where("assignee = ?", *filter.Assignee)
+	}
+	if filter.DatetimeFrom != nil {
+		from, err := parseDateTime(*filter.DatetimeFrom)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("date_time >= ?", from)
+	}
+	if filter.DatetimeTo != nil {
+		to, err := parseDateTime(*filter.DatetimeTo)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("date_time < ?", to)
+	}
+	if filter.FromEmail != nil {
+		query = query.Where("LOWER(from_email) = ?", strings.ToLower(*filter.FromEmail))
+	}
+	if filter.ToEmail != nil {
+		query = query.Where("LOWER(to_email) = ?", strings.ToLower(*filter.ToEmail))
+	}
+	return query, nil
+}
+
+// pageSize は first 引数を既定値と上限に収める
+func pageSize(first *int) (int, error) {
+	if first == nil {
+		return defaultPageSize, nil
+	}
+	if *first < 0 {
+		return 0, fmt.Errorf("first must not be negative")
+	}
+	if *first > maxPageSize {
+		return maxPageSize, nil
+	}
+	return *first, nil
+}
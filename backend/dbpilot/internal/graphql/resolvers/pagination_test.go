@@ -0,0 +1,300 @@
+package resolvers
+
+import (
+	"context"
+	"dbpilot/internal/graphql/generated"
+	"dbpilot/internal/models"
+	"encoding/base64"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"gorm.io/gorm"
+)
+
+// seedPageIncidents は指定した日時のインシデントを順に作成する
+func seedPageIncidents(t *testing.T, db *gorm.DB, times ...time.Time) []*models.Incident {
+	t.Helper()
+
+	incidents := make([]*models.Incident, len(times))
+	for i, at := range times {
+		incidents[i] = &models.Incident{
+			DateTime:  at,
+			Status:    models.IncidentStatusNew,
+			Judgment:  models.IncidentJudgmentMonitor,
+			Content:   "content",
+			Assignee:  "yamada",
+			Priority:  models.IncidentPriorityLow,
+			FromEmail: "alert@example.com",
+			ToEmail:   "ops@example.com",
+			Subject:   "subject",
+		}
+	}
+	if err := db.Create(&incidents).Error; err != nil {
+		t.Fatalf("failed to create incidents: %v", err)
+	}
+	return incidents
+}
+
+// collectIncidentPages は pageSize 件ずつ最後のページまで取得し、ID を取得順に返す
+func collectIncidentPages(t *testing.T, r *Resolver, pageSize int, orderBy *models.IncidentOrder) []uint {
+	t.Helper()
+
+	var ids []uint
+	var after *string
+	for page := 0; ; page++ {
+		if page > 100 {
+			t.Fatal("pagination did not terminate")
+		}
+		conn, err := r.Query().IncidentsConnection(context.Background(), &pageSize, after, nil, orderBy)
+		if err != nil {
+			t.Fatalf("IncidentsConnection: %v", err)
+		}
+		for _, edge := range conn.Edges {
+			ids = append(ids, edge.Node.ID)
+		}
+		if !conn.PageInfo.HasNextPage {
+			return ids
+		}
+		after = conn.PageInfo.EndCursor
+	}
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestIncidentCursorRoundTrip(t *testing.T) {
+	incident := &models.Incident{ID: 42, DateTime: time.Date(2024, 4, 1, 9, 0, 0, 123456789, time.UTC)}
+
+	cursor, err := decodeIncidentCursor(encodeIncidentCursor(models.IncidentOrderFieldDatetime, incident), models.IncidentOrderFieldDatetime)
+	if err != nil {
+		t.Fatalf("decodeIncidentCursor: %v", err)
+	}
+	if cursor.ID != 42 || cursor.Value != "2024-04-01T09:00:00.123456789Z" {
+		t.Errorf("cursor = %+v, want id 42 at the incident datetime", cursor)
+	}
+}
+
+func TestDecodeIncidentCursorRejectsTamperedCursors(t *testing.T) {
+	valid := encodeIncidentCursor(models.IncidentOrderFieldDatetime, &models.Incident{ID: 1, DateTime: time.Now()})
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	cursors := map[string]string{
+		"not base64":      "!!!",
+		"padded base64":   base64.URLEncoding.EncodeToString([]byte(`{"f":"ID","id":1}`)),
+		"not json":        encode("not json"),
+		"missing id":      encode(`{"f":"DATETIME","v":"2024-04-01T09:00:00Z"}`),
+		"truncated":       valid[:len(valid)/2],
+		"different order": encodeIncidentCursor(models.IncidentOrderFieldCreatedAt, &models.Incident{ID: 1}),
+	}
+	for name, cursor := range cursors {
+		if _, err := decodeIncidentCursor(cursor, models.IncidentOrderFieldDatetime); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
+
+func TestIncidentsConnectionRejectsTamperedCursorValue(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	seedPageIncidents(t, r.DB, time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC))
+
+	after := base64.RawURLEncoding.EncodeToString([]byte(`{"f":"DATETIME","v":"yesterday","id":1}`))
+	if _, err := r.Query().IncidentsConnection(context.Background(), nil, &after, nil, nil); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("err = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestIncidentsConnectionPagesThroughTies(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	early := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	// 同じ日時のインシデントがページの境界をまたぐようにする
+	seeded := seedPageIncidents(t, r.DB, late, early, late, early, late)
+	id := func(i int) uint { return seeded[i].ID }
+
+	tests := []struct {
+		name    string
+		orderBy *models.IncidentOrder
+		want    []uint
+	}{
+		{
+			name: "default order",
+			want: []uint{id(4), id(2), id(0), id(3), id(1)},
+		},
+		{
+			name:    "datetime ascending",
+			orderBy: &models.IncidentOrder{Field: models.IncidentOrderFieldDatetime, Direction: models.OrderDirectionAsc},
+			want:    []uint{id(1), id(3), id(0), id(2), id(4)},
+		},
+		{
+			name:    "id descending",
+			orderBy: &models.IncidentOrder{Field: models.IncidentOrderFieldID, Direction: models.OrderDirectionDesc},
+			want:    []uint{id(4), id(3), id(2), id(1), id(0)},
+		},
+	}
+	for _, tt := range tests {
+		for _, size := range []int{1, 2, 5} {
+			if got := collectIncidentPages(t, r, size, tt.orderBy); !equalIDs(got, tt.want) {
+				t.Errorf("%s, %d per page: ids = %v, want %v", tt.name, size, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestIncidentsConnectionFilters(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	base := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	seeded := seedPageIncidents(t, r.DB, base, base.Add(time.Hour), base.Add(2*time.Hour))
+
+	updates := []map[string]interface{}{
+		{"status": models.IncidentStatusTriaged, "priority": models.IncidentPriorityHigh},
+		{"assignee": "suzuki", "from_email": "Alert@Example.com"},
+		{"status": models.IncidentStatusTriaged, "to_email": "security@example.com"},
+	}
+	for i, update := range updates {
+		if err := r.DB.Model(seeded[i]).Updates(update).Error; err != nil {
+			t.Fatalf("failed to update incident: %v", err)
+		}
+	}
+
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		name   string
+		filter models.IncidentFilter
+		want   []int
+	}{
+		{"status", models.IncidentFilter{Status: []models.IncidentStatus{models.IncidentStatusTriaged}}, []int{2, 0}},
+		{"priority", models.IncidentFilter{Priority: []models.IncidentPriority{models.IncidentPriorityHigh}}, []int{0}},
+		{"judgment", models.IncidentFilter{Judgment: []models.IncidentJudgment{models.IncidentJudgmentActionRequired}}, nil},
+		{"assignee", models.IncidentFilter{Assignee: str("suzuki")}, []int{1}},
+		{"datetime range", models.IncidentFilter{DatetimeFrom: str("2024-04-01T10:00:00Z"), DatetimeTo: str("2024-04-01T11:00:00Z")}, []int{1}},
+		{"from email ignores case", models.IncidentFilter{FromEmail: str("alert@example.com")}, []int{2, 1, 0}},
+		{"to email", models.IncidentFilter{ToEmail: str("SECURITY@example.com")}, []int{2}},
+		{"combined", models.IncidentFilter{Status: []models.IncidentStatus{models.IncidentStatusTriaged}, ToEmail: str("ops@example.com")}, []int{0}},
+	}
+	for _, tt := range tests {
+		conn, err := r.Query().IncidentsConnection(context.Background(), nil, nil, &tt.filter, nil)
+		if err != nil {
+			t.Fatalf("%s: IncidentsConnection: %v", tt.name, err)
+		}
+		var got, want []uint
+		for _, edge := range conn.Edges {
+			got = append(got, edge.Node.ID)
+		}
+		for _, i := range tt.want {
+			want = append(want, seeded[i].ID)
+		}
+		if !equalIDs(got, want) {
+			t.Errorf("%s: ids = %v, want %v", tt.name, got, want)
+		}
+
+		count, err := r.IncidentConnection().TotalCount(context.Background(), conn)
+		if err != nil {
+			t.Fatalf("%s: TotalCount: %v", tt.name, err)
+		}
+		if count != len(tt.want) {
+			t.Errorf("%s: totalCount = %d, want %d", tt.name, count, len(tt.want))
+		}
+	}
+
+	bad := models.IncidentFilter{DatetimeFrom: str("2024-04-01")}
+	if _, err := r.Query().IncidentsConnection(context.Background(), nil, nil, &bad, nil); err == nil {
+		t.Error("expected a malformed datetime filter to be rejected")
+	}
+}
+
+func TestIncidentsConnectionPageSize(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	times := make([]time.Time, maxPageSize+1)
+	for i := range times {
+		times[i] = time.Date(2024, 4, 1, 9, 0, i, 0, time.UTC)
+	}
+	seedPageIncidents(t, r.DB, times...)
+
+	size := func(n int) *int { return &n }
+	tests := []struct {
+		name        string
+		first       *int
+		wantEdges   int
+		wantHasNext bool
+	}{
+		{"default", nil, defaultPageSize, true},
+		{"zero", size(0), 0, true},
+		{"within limit", size(maxPageSize), maxPageSize, true},
+		{"over limit", size(maxPageSize + 50), maxPageSize, true},
+	}
+	for _, tt := range tests {
+		conn, err := r.Query().IncidentsConnection(context.Background(), tt.first, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: IncidentsConnection: %v", tt.name, err)
+		}
+		if len(conn.Edges) != tt.wantEdges || conn.PageInfo.HasNextPage != tt.wantHasNext {
+			t.Errorf("%s: edges = %d, hasNextPage = %v, want %d, %v",
+				tt.name, len(conn.Edges), conn.PageInfo.HasNextPage, tt.wantEdges, tt.wantHasNext)
+		}
+	}
+
+	if _, err := r.Query().IncidentsConnection(context.Background(), size(-1), nil, nil, nil); err == nil {
+		t.Error("expected a negative first to be rejected")
+	}
+}
+
+func TestIncidentsConnectionCountsOnlyWhenSelected(t *testing.T) {
+	db := newTestDB(t)
+	seedPageIncidents(t, db, time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC))
+
+	var queries atomic.Int64
+	if err := db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) {
+		queries.Add(1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 権限の確認はこのテストの対象外なので素通しする
+	config := generated.Config{
+		Resolvers: &Resolver{DB: db},
+		Directives: generated.DirectiveRoot{
+			HasPermission: func(ctx context.Context, obj interface{}, next graphql.Resolver, permission string) (interface{}, error) {
+				return next(ctx)
+			},
+		},
+	}
+	c := client.New(handler.NewDefaultServer(generated.NewExecutableSchema(config)))
+
+	var page struct {
+		IncidentsConnection struct {
+			Edges []struct{ Node struct{ ID string } }
+		}
+	}
+	c.MustPost(`{ incidentsConnection(first: 1) { edges { node { id } } } }`, &page)
+	if got := queries.Swap(0); got != 1 {
+		t.Errorf("without totalCount: %d queries, want 1", got)
+	}
+
+	var counted struct {
+		IncidentsConnection struct {
+			Edges      []struct{ Node struct{ ID string } }
+			TotalCount int
+		}
+	}
+	c.MustPost(`{ incidentsConnection(first: 1) { edges { node { id } } totalCount } }`, &counted)
+	if got := queries.Swap(0); got != 2 {
+		t.Errorf("with totalCount: %d queries, want 2", got)
+	}
+	if len(counted.IncidentsConnection.Edges) != 1 || counted.IncidentsConnection.TotalCount != 2 {
+		t.Errorf("connection = %+v, want 1 edge and totalCount 2", counted.IncidentsConnection)
+	}
+}
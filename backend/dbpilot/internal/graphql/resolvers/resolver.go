@@ -1,6 +1,10 @@
 package resolvers
 
 import (
+	"context"
+	"dbpilot/internal/graphql/loaders"
+	"dbpilot/internal/workflow"
+
 	"gorm.io/gorm"
 )
 
@@ -9,5 +13,15 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Workflow *workflow.Workflow
+}
+
+// loaders はリクエストのデータローダーを返す。
+// ミドルウェアを経由しない呼び出しでは、その場限りのローダーを作成する。
+func (r *Resolver) loaders(ctx context.Context) *loaders.Loaders {
+	if l, ok := loaders.For(ctx); ok {
+		return l
+	}
+	return loaders.New(r.DB)
 }
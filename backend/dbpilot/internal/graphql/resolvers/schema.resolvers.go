@@ -9,7 +9,11 @@ import (
 	"dbpilot/internal/graphql/generated"
 	"dbpilot/internal/models"
 	"fmt"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ID はインシデントIDを文字列として返します
@@ -22,43 +26,109 @@ func (r *incidentResolver) Datetime(ctx context.Context, obj *models.Incident) (
 	return obj.DateTime.Format(time.RFC3339), nil
 }
 
-// CreatedAt is the resolver for the createdAt field.
+// Responses はインシデントの対応履歴をデータローダー経由で返します
+func (r *incidentResolver) Responses(ctx context.Context, obj *models.Incident) ([]*models.Response, error) {
+	return r.loaders(ctx).ResponsesByIncident.Load(ctx, obj.ID)()
+}
+
+// RelatedToIncidents はこのインシデントを関連元とする関連をデータローダー経由で返します
+func (r *incidentResolver) RelatedToIncidents(ctx context.Context, obj *models.Incident) ([]*models.IncidentRelation, error) {
+	return r.loaders(ctx).RelatedToIncidents.Load(ctx, obj.ID)()
+}
+
+// RelatedFromIncidents はこのインシデントを関連先とする関連をデータローダー経由で返します
+func (r *incidentResolver) RelatedFromIncidents(ctx context.Context, obj *models.Incident) ([]*models.IncidentRelation, error) {
+	return r.loaders(ctx).RelatedFromIncidents.Load(ctx, obj.ID)()
+}
+
+// StatusHistory はインシデントの状態遷移の履歴をデータローダー経由で返します
+func (r *incidentResolver) StatusHistory(ctx context.Context, obj *models.Incident) ([]*models.IncidentStatusHistory, error) {
+	return r.loaders(ctx).StatusHistoryByIncident.Load(ctx, obj.ID)()
+}
+
+// CreatedAt は作成日時を文字列として返します
 func (r *incidentResolver) CreatedAt(ctx context.Context, obj *models.Incident) (string, error) {
-	panic(fmt.Errorf("not implemented: CreatedAt - createdAt"))
+	return formatTime(obj.CreatedAt), nil
 }
 
-// UpdatedAt is the resolver for the updatedAt field.
+// UpdatedAt は更新日時を文字列として返します
 func (r *incidentResolver) UpdatedAt(ctx context.Context, obj *models.Incident) (string, error) {
-	panic(fmt.Errorf("not implemented: UpdatedAt - updatedAt"))
+	return formatTime(obj.UpdatedAt), nil
 }
 
-// ID is the resolver for the id field.
+// TotalCount は絞り込み条件に一致するインシデントの総数を返します
+func (r *incidentConnectionResolver) TotalCount(ctx context.Context, obj *models.IncidentConnection) (int, error) {
+	query, err := applyIncidentFilter(r.DB.WithContext(ctx).Model(&models.Incident{}), obj.Filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count incidents: %v", err)
+	}
+	return int(count), nil
+}
+
+// ID は関連IDを文字列として返します
 func (r *incidentRelationResolver) ID(ctx context.Context, obj *models.IncidentRelation) (string, error) {
-	panic(fmt.Errorf("not implemented: ID - id"))
+	return formatID(obj.ID), nil
 }
 
-// IncidentID is the resolver for the incidentId field.
+// IncidentID は関連元インシデントのIDを文字列として返します
 func (r *incidentRelationResolver) IncidentID(ctx context.Context, obj *models.IncidentRelation) (string, error) {
-	panic(fmt.Errorf("not implemented: IncidentID - incidentId"))
+	return formatID(obj.IncidentID), nil
 }
 
-// RelatedIncidentID is the resolver for the relatedIncidentId field.
+// RelatedIncidentID は関連先インシデントのIDを文字列として返します
 func (r *incidentRelationResolver) RelatedIncidentID(ctx context.Context, obj *models.IncidentRelation) (string, error) {
-	panic(fmt.Errorf("not implemented: RelatedIncidentID - relatedIncidentId"))
+	return formatID(obj.RelatedIncidentID), nil
 }
 
-// CreatedAt is the resolver for the createdAt field.
+// CreatedAt は作成日時を文字列として返します
 func (r *incidentRelationResolver) CreatedAt(ctx context.Context, obj *models.IncidentRelation) (string, error) {
-	panic(fmt.Errorf("not implemented: CreatedAt - createdAt"))
+	return formatTime(obj.CreatedAt), nil
 }
 
-// UpdatedAt is the resolver for the updatedAt field.
+// UpdatedAt は更新日時を文字列として返します
 func (r *incidentRelationResolver) UpdatedAt(ctx context.Context, obj *models.IncidentRelation) (string, error) {
-	panic(fmt.Errorf("not implemented: UpdatedAt - updatedAt"))
+	return formatTime(obj.UpdatedAt), nil
+}
+
+// ID は履歴IDを文字列として返します
+func (r *incidentStatusHistoryResolver) ID(ctx context.Context, obj *models.IncidentStatusHistory) (string, error) {
+	return formatID(obj.ID), nil
+}
+
+// IncidentID はインシデントIDを文字列として返します
+func (r *incidentStatusHistoryResolver) IncidentID(ctx context.Context, obj *models.IncidentStatusHistory) (string, error) {
+	return formatID(obj.IncidentID), nil
+}
+
+// ActorID は遷移を行ったユーザーのIDを文字列として返します
+func (r *incidentStatusHistoryResolver) ActorID(ctx context.Context, obj *models.IncidentStatusHistory) (*string, error) {
+	if obj.ActorID == nil {
+		return nil, nil
+	}
+	id := formatID(*obj.ActorID)
+	return &id, nil
+}
+
+// CreatedAt は遷移した日時を文字列として返します
+func (r *incidentStatusHistoryResolver) CreatedAt(ctx context.Context, obj *models.IncidentStatusHistory) (string, error) {
+	return formatTime(obj.CreatedAt), nil
 }
 
 // CreateIncident は新しいインシデントを作成します
 func (r *mutationResolver) CreateIncident(ctx context.Context, input models.IncidentInput) (*models.Incident, error) {
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+	// 作成時の状態もワークフローに従う（既定では new のみ）
+	if err := r.workflow().Check("", input.Status); err != nil {
+		return nil, err
+	}
+
 	datetime, err := time.Parse(time.RFC3339, input.DateTime)
 	if err != nil {
 		return nil, fmt.Errorf("invalid datetime format: %v", err)
@@ -76,8 +146,14 @@ func (r *mutationResolver) CreateIncident(ctx context.Context, input models.Inci
 		Subject:   input.Subject,
 	}
 
-	if err := r.DB.Create(incident).Error; err != nil {
-		return nil, fmt.Errorf("failed to create incident: %v", err)
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(incident).Error; err != nil {
+			return fmt.Errorf("failed to create incident: %v", err)
+		}
+		return recordStatusChange(ctx, tx, incident.ID, nil, incident.Status, nil)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// データベースから新しく作成されたインシデントを取得
@@ -89,50 +165,353 @@ func (r *mutationResolver) CreateIncident(ctx context.Context, input models.Inci
 	return createdIncident, nil
 }
 
-// UpdateIncident is the resolver for the updateIncident field.
+// UpdateIncident は指定されたIDのインシデントを更新します
 func (r *mutationResolver) UpdateIncident(ctx context.Context, id string, input models.IncidentInput) (*models.Incident, error) {
-	panic(fmt.Errorf("not implemented: UpdateIncident - updateIncident"))
+	incidentID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	datetime, err := parseDateTime(input.DateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var incident models.Incident
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&incident, incidentID).Error; err != nil {
+			return notFound(err, "incident", incidentID)
+		}
+
+		// 状態の変更はワークフローで許可された遷移に限る
+		previousStatus := incident.Status
+		if input.Status != previousStatus {
+			if err := r.workflow().Check(previousStatus, input.Status); err != nil {
+				return err
+			}
+		}
+
+		incident.DateTime = datetime
+		incident.Status = input.Status
+		incident.Judgment = input.Judgment
+		incident.Content = input.Content
+		incident.Assignee = input.Assignee
+		incident.Priority = input.Priority
+		incident.FromEmail = input.FromEmail
+		incident.ToEmail = input.ToEmail
+		incident.Subject = input.Subject
+
+		if err := tx.Save(&incident).Error; err != nil {
+			return fmt.Errorf("failed to update incident: %v", err)
+		}
+
+		if incident.Status != previousStatus {
+			return recordStatusChange(ctx, tx, incident.ID, &previousStatus, incident.Status, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &incident, nil
 }
 
-// DeleteIncident is the resolver for the deleteIncident field.
+// TransitionIncident はワークフローに従ってインシデントの状態を遷移させ、履歴を記録します
+func (r *mutationResolver) TransitionIncident(ctx context.Context, id string, to models.IncidentStatus, comment *string) (*models.Incident, error) {
+	incidentID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var incident models.Incident
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&incident, incidentID).Error; err != nil {
+			return notFound(err, "incident", incidentID)
+		}
+
+		from := incident.Status
+		if err := r.workflow().Check(from, to); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&incident).Update("status", to).Error; err != nil {
+			return fmt.Errorf("failed to update incident status: %v", err)
+		}
+		incident.Status = to
+
+		return recordStatusChange(ctx, tx, incident.ID, &from, to, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &incident, nil
+}
+
+// DeleteIncident は指定されたIDのインシデントと、その対応履歴・関連・状態遷移の履歴を削除します
 func (r *mutationResolver) DeleteIncident(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteIncident - deleteIncident"))
+	incidentID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureIncidentExists(tx, incidentID); err != nil {
+			return err
+		}
+
+		// 外部キーの CASCADE に頼らず、関連するレコードを明示的に削除する
+		if err := tx.Where("incident_id = ?", incidentID).Delete(&models.Response{}).Error; err != nil {
+			return fmt.Errorf("failed to delete responses: %v", err)
+		}
+		if err := tx.Where("incident_id = ? OR related_incident_id = ?", incidentID, incidentID).
+			Delete(&models.IncidentRelation{}).Error; err != nil {
+			return fmt.Errorf("failed to delete incident relations: %v", err)
+		}
+		if err := tx.Where("incident_id = ?", incidentID).Delete(&models.IncidentStatusHistory{}).Error; err != nil {
+			return fmt.Errorf("failed to delete status history: %v", err)
+		}
+		if err := tx.Delete(&models.Incident{}, incidentID).Error; err != nil {
+			return fmt.Errorf("failed to delete incident: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
-// CreateResponse is the resolver for the createResponse field.
+// CreateResponse はインシデントに対応履歴を追加します
 func (r *mutationResolver) CreateResponse(ctx context.Context, input models.ResponseInput) (*models.Response, error) {
-	panic(fmt.Errorf("not implemented: CreateResponse - createResponse"))
+	datetime, err := parseDateTime(input.DateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.Response{
+		IncidentID: input.IncidentID,
+		DateTime:   datetime,
+		Responder:  input.Responder,
+		Content:    input.Content,
+	}
+
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureIncidentExists(tx, input.IncidentID); err != nil {
+			return err
+		}
+		if err := tx.Create(response).Error; err != nil {
+			return fmt.Errorf("failed to create response: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
 }
 
-// UpdateResponse is the resolver for the updateResponse field.
+// UpdateResponse は指定されたIDの対応履歴を更新します
 func (r *mutationResolver) UpdateResponse(ctx context.Context, id string, input models.ResponseInput) (*models.Response, error) {
-	panic(fmt.Errorf("not implemented: UpdateResponse - updateResponse"))
+	responseID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	datetime, err := parseDateTime(input.DateTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.Response
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&response, responseID).Error; err != nil {
+			return notFound(err, "response", responseID)
+		}
+
+		if input.IncidentID != response.IncidentID {
+			if err := ensureIncidentExists(tx, input.IncidentID); err != nil {
+				return err
+			}
+		}
+
+		response.IncidentID = input.IncidentID
+		response.DateTime = datetime
+		response.Responder = input.Responder
+		response.Content = input.Content
+
+		if err := tx.Save(&response).Error; err != nil {
+			return fmt.Errorf("failed to update response: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
 }
 
-// DeleteResponse is the resolver for the deleteResponse field.
+// DeleteResponse は指定されたIDの対応履歴を削除します
 func (r *mutationResolver) DeleteResponse(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteResponse - deleteResponse"))
+	responseID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	result := r.DB.WithContext(ctx).Delete(&models.Response{}, responseID)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete response: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, notFound(gorm.ErrRecordNotFound, "response", responseID)
+	}
+
+	return true, nil
 }
 
-// CreateIncidentRelation is the resolver for the createIncidentRelation field.
+// CreateIncidentRelation は二つのインシデントを関連付けます
 func (r *mutationResolver) CreateIncidentRelation(ctx context.Context, input models.IncidentRelationInput) (*models.IncidentRelation, error) {
-	panic(fmt.Errorf("not implemented: CreateIncidentRelation - createIncidentRelation"))
+	if input.IncidentID == input.RelatedIncidentID {
+		return nil, ErrSelfRelation
+	}
+
+	relation := &models.IncidentRelation{
+		IncidentID:        input.IncidentID,
+		RelatedIncidentID: input.RelatedIncidentID,
+	}
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureIncidentExists(tx, input.IncidentID); err != nil {
+			return err
+		}
+		if err := ensureIncidentExists(tx, input.RelatedIncidentID); err != nil {
+			return err
+		}
+
+		// 関連は向きを問わず一組につき一件まで。同時に作成された場合も
+		// idx_incident_relations_unique_pair で弾かれるため、その違反を重複として扱う
+		if err := tx.Create(relation).Error; err != nil {
+			if isUniqueViolation(tx, err) {
+				return ErrDuplicateRelation
+			}
+			return fmt.Errorf("failed to create incident relation: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	createdRelation := &models.IncidentRelation{}
+	if err := r.DB.WithContext(ctx).Preload("Incident").Preload("RelatedIncident").
+		First(createdRelation, relation.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve created incident relation: %v", err)
+	}
+
+	return createdRelation, nil
 }
 
-// DeleteIncidentRelation is the resolver for the deleteIncidentRelation field.
+// DeleteIncidentRelation は指定されたIDのインシデント関連を削除します
 func (r *mutationResolver) DeleteIncidentRelation(ctx context.Context, id string) (bool, error) {
-	panic(fmt.Errorf("not implemented: DeleteIncidentRelation - deleteIncidentRelation"))
+	relationID, err := parseID(id)
+	if err != nil {
+		return false, err
+	}
+
+	result := r.DB.WithContext(ctx).Delete(&models.IncidentRelation{}, relationID)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete incident relation: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, notFound(gorm.ErrRecordNotFound, "incident relation", relationID)
+	}
+
+	return true, nil
 }
 
-// Incidents は全てのインシデントを返します
+// Incidents は新しい順にインシデントを返します。件数は incidentsConnection の上限に揃えます
 func (r *queryResolver) Incidents(ctx context.Context) ([]*models.Incident, error) {
 	var incidents []*models.Incident
-	if err := r.DB.Find(&incidents).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Order("date_time desc, id desc").Limit(maxPageSize).Find(&incidents).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch incidents: %v", err)
 	}
 	return incidents, nil
 }
 
+// IncidentsConnection はインシデントをカーソル方式でページ分割して返します
+func (r *queryResolver) IncidentsConnection(ctx context.Context, first *int, after *string, filter *models.IncidentFilter, orderBy *models.IncidentOrder) (*models.IncidentConnection, error) {
+	limit, err := pageSize(first)
+	if err != nil {
+		return nil, err
+	}
+
+	order := defaultIncidentOrder
+	if orderBy != nil {
+		order = *orderBy
+	}
+
+	query, err := applyIncidentFilter(r.DB.WithContext(ctx), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if after != nil && *after != "" {
+		cursor, err := decodeIncidentCursor(*after, order.Field)
+		if err != nil {
+			return nil, err
+		}
+		if query, err = applyIncidentCursor(query, order, cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// 次ページの有無を判定するため1件多く取得する
+	direction := strings.ToLower(order.Direction.String())
+	if order.Field != models.IncidentOrderFieldID {
+		query = query.Order(order.Field.Column() + " " + direction)
+	}
+	var incidents []*models.Incident
+	if err := query.Order("id " + direction).Limit(limit + 1).Find(&incidents).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch incidents: %v", err)
+	}
+
+	hasNextPage := len(incidents) > limit
+	if hasNextPage {
+		incidents = incidents[:limit]
+	}
+
+	edges := make([]*models.IncidentEdge, len(incidents))
+	for i, incident := range incidents {
+		edges[i] = &models.IncidentEdge{
+			Cursor: encodeIncidentCursor(order.Field, incident),
+			Node:   incident,
+		}
+	}
+
+	pageInfo := &models.PageInfo{
+		HasNextPage:     hasNextPage,
+		HasPreviousPage: after != nil && *after != "",
+	}
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &models.IncidentConnection{
+		Edges:    edges,
+		PageInfo: pageInfo,
+		Filter:   filter,
+	}, nil
+}
+
 // Incident は指定されたIDのインシデントを返します
 func (r *queryResolver) Incident(ctx context.Context, id string) (*models.Incident, error) {
 	var incident models.Incident
@@ -142,64 +521,109 @@ func (r *queryResolver) Incident(ctx context.Context, id string) (*models.Incide
 	return &incident, nil
 }
 
-// Responses is the resolver for the responses field.
+// Responses は指定されたインシデントの対応履歴を日時順に返します
 func (r *queryResolver) Responses(ctx context.Context, incidentID string) ([]*models.Response, error) {
-	panic(fmt.Errorf("not implemented: Responses - responses"))
+	id, err := parseID(incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*models.Response
+	if err := r.DB.WithContext(ctx).Where("incident_id = ?", id).Order("date_time, id").Find(&responses).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch responses: %v", err)
+	}
+	return responses, nil
 }
 
-// Relations is the resolver for the relations field.
+// Relations は指定されたインシデントを関連元または関連先とする関連を返します
 func (r *queryResolver) Relations(ctx context.Context, incidentID string) ([]*models.IncidentRelation, error) {
-	panic(fmt.Errorf("not implemented: Relations - relations"))
+	id, err := parseID(incidentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var relations []*models.IncidentRelation
+	if err := r.DB.WithContext(ctx).Preload("Incident").Preload("RelatedIncident").
+		Where("incident_id = ? OR related_incident_id = ?", id, id).
+		Order("id").Find(&relations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch incident relations: %v", err)
+	}
+	return relations, nil
 }
 
-// ID is the resolver for the id field.
+// ID は対応履歴IDを文字列として返します
 func (r *responseResolver) ID(ctx context.Context, obj *models.Response) (string, error) {
-	panic(fmt.Errorf("not implemented: ID - id"))
+	return formatID(obj.ID), nil
 }
 
-// IncidentID is the resolver for the incidentId field.
+// IncidentID はインシデントIDを文字列として返します
 func (r *responseResolver) IncidentID(ctx context.Context, obj *models.Response) (string, error) {
-	panic(fmt.Errorf("not implemented: IncidentID - incidentId"))
+	return formatID(obj.IncidentID), nil
 }
 
-// Datetime is the resolver for the datetime field.
+// Datetime は対応日時を文字列として返します
 func (r *responseResolver) Datetime(ctx context.Context, obj *models.Response) (string, error) {
-	panic(fmt.Errorf("not implemented: Datetime - datetime"))
+	return formatTime(obj.DateTime), nil
 }
 
-// CreatedAt is the resolver for the createdAt field.
+// CreatedAt は作成日時を文字列として返します
 func (r *responseResolver) CreatedAt(ctx context.Context, obj *models.Response) (string, error) {
-	panic(fmt.Errorf("not implemented: CreatedAt - createdAt"))
+	return formatTime(obj.CreatedAt), nil
 }
 
-// UpdatedAt is the resolver for the updatedAt field.
+// UpdatedAt は更新日時を文字列として返します
 func (r *responseResolver) UpdatedAt(ctx context.Context, obj *models.Response) (string, error) {
-	panic(fmt.Errorf("not implemented: UpdatedAt - updatedAt"))
+	return formatTime(obj.UpdatedAt), nil
 }
 
-// IncidentID is the resolver for the incidentId field.
+// IncidentID は関連元インシデントのIDを入力から取り込みます
 func (r *incidentRelationInputResolver) IncidentID(ctx context.Context, obj *models.IncidentRelationInput, data string) error {
-	panic(fmt.Errorf("not implemented: IncidentID - incidentId"))
+	id, err := parseID(data)
+	if err != nil {
+		return err
+	}
+	obj.IncidentID = id
+	return nil
 }
 
-// RelatedIncidentID is the resolver for the relatedIncidentId field.
+// RelatedIncidentID は関連先インシデントのIDを入力から取り込みます
 func (r *incidentRelationInputResolver) RelatedIncidentID(ctx context.Context, obj *models.IncidentRelationInput, data string) error {
-	panic(fmt.Errorf("not implemented: RelatedIncidentID - relatedIncidentId"))
+	id, err := parseID(data)
+	if err != nil {
+		return err
+	}
+	obj.RelatedIncidentID = id
+	return nil
 }
 
-// IncidentID is the resolver for the incidentId field.
+// IncidentID はインシデントIDを入力から取り込みます
 func (r *responseInputResolver) IncidentID(ctx context.Context, obj *models.ResponseInput, data string) error {
-	panic(fmt.Errorf("not implemented: IncidentID - incidentId"))
+	id, err := parseID(data)
+	if err != nil {
+		return err
+	}
+	obj.IncidentID = id
+	return nil
 }
 
 // Incident returns generated.IncidentResolver implementation.
 func (r *Resolver) Incident() generated.IncidentResolver { return &incidentResolver{r} }
 
+// IncidentConnection returns generated.IncidentConnectionResolver implementation.
+func (r *Resolver) IncidentConnection() generated.IncidentConnectionResolver {
+	return &incidentConnectionResolver{r}
+}
+
 // IncidentRelation returns generated.IncidentRelationResolver implementation.
 func (r *Resolver) IncidentRelation() generated.IncidentRelationResolver {
 	return &incidentRelationResolver{r}
 }
 
+// IncidentStatusHistory returns generated.IncidentStatusHistoryResolver implementation.
+func (r *Resolver) IncidentStatusHistory() generated.IncidentStatusHistoryResolver {
+	return &incidentStatusHistoryResolver{r}
+}
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
@@ -218,7 +642,9 @@ func (r *Resolver) IncidentRelationInput() generated.IncidentRelationInputResolv
 func (r *Resolver) ResponseInput() generated.ResponseInputResolver { return &responseInputResolver{r} }
 
 type incidentResolver struct{ *Resolver }
+type incidentConnectionResolver struct{ *Resolver }
 type incidentRelationResolver struct{ *Resolver }
+type incidentStatusHistoryResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type responseResolver struct{ *Resolver }
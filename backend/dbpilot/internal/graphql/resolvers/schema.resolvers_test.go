@@ -0,0 +1,308 @@
+package resolvers
+
+import (
+	"context"
+	"dbpilot/internal/models"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// failWrites は指定したテーブルへの書き込みを失敗させるコールバックを登録する
+func failWrites(t *testing.T, db *gorm.DB, table string) {
+	t.Helper()
+
+	fail := func(tx *gorm.DB) {
+		if tx.Statement.Table == table {
+			tx.AddError(errors.New("injected failure"))
+		}
+	}
+	if err := db.Callback().Create().Before("gorm:create").Register("test:fail_create", fail); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").Register("test:fail_delete", fail); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMutationsRejectMalformedIDs(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	m := r.Mutation()
+	ctx := context.Background()
+
+	responseInput := models.ResponseInput{DateTime: "2024-04-01T10:00:00Z", Responder: "yamada", Content: "調査開始"}
+
+	for _, id := range []string{"", "abc", "0", "-1", "1.5", "99999999999"} {
+		calls := map[string]func() error{
+			"updateIncident": func() error {
+				_, err := m.UpdateIncident(ctx, id, newIncidentInput())
+				return err
+			},
+			"deleteIncident": func() error {
+				_, err := m.DeleteIncident(ctx, id)
+				return err
+			},
+			"transitionIncident": func() error {
+				_, err := m.TransitionIncident(ctx, id, models.IncidentStatusTriaged, nil)
+				return err
+			},
+			"updateResponse": func() error {
+				_, err := m.UpdateResponse(ctx, id, responseInput)
+				return err
+			},
+			"deleteResponse": func() error {
+				_, err := m.DeleteResponse(ctx, id)
+				return err
+			},
+			"deleteIncidentRelation": func() error {
+				_, err := m.DeleteIncidentRelation(ctx, id)
+				return err
+			},
+		}
+		for name, call := range calls {
+			if err := call(); !errors.Is(err, ErrInvalidID) {
+				t.Errorf("%s(%q): got %v, want ErrInvalidID", name, id, err)
+			}
+		}
+	}
+}
+
+func TestInputResolversRejectMalformedIDs(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	ctx := context.Background()
+
+	var responseInput models.ResponseInput
+	if err := r.ResponseInput().IncidentID(ctx, &responseInput, "abc"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("ResponseInput.incidentId: got %v, want ErrInvalidID", err)
+	}
+
+	var relationInput models.IncidentRelationInput
+	if err := r.IncidentRelationInput().RelatedIncidentID(ctx, &relationInput, "0"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("IncidentRelationInput.relatedIncidentId: got %v, want ErrInvalidID", err)
+	}
+	if err := r.IncidentRelationInput().IncidentID(ctx, &relationInput, "42"); err != nil || relationInput.IncidentID != 42 {
+		t.Errorf("IncidentRelationInput.incidentId: got %v (id %d), want 42", err, relationInput.IncidentID)
+	}
+}
+
+func TestMutationsReturnNotFound(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	m := r.Mutation()
+	ctx := context.Background()
+
+	incident := createTestIncident(t, r)
+	missing := "9999"
+
+	responseInput := models.ResponseInput{IncidentID: 9999, DateTime: "2024-04-01T10:00:00Z", Responder: "yamada", Content: "調査開始"}
+
+	calls := map[string]func() error{
+		"updateIncident": func() error {
+			_, err := m.UpdateIncident(ctx, missing, newIncidentInput())
+			return err
+		},
+		"deleteIncident": func() error {
+			_, err := m.DeleteIncident(ctx, missing)
+			return err
+		},
+		"transitionIncident": func() error {
+			_, err := m.TransitionIncident(ctx, missing, models.IncidentStatusTriaged, nil)
+			return err
+		},
+		"createResponse with unknown incident": func() error {
+			_, err := m.CreateResponse(ctx, responseInput)
+			return err
+		},
+		"updateResponse": func() error {
+			_, err := m.UpdateResponse(ctx, missing, responseInput)
+			return err
+		},
+		"deleteResponse": func() error {
+			_, err := m.DeleteResponse(ctx, missing)
+			return err
+		},
+		"createIncidentRelation with unknown related incident": func() error {
+			_, err := m.CreateIncidentRelation(ctx, models.IncidentRelationInput{IncidentID: incident.ID, RelatedIncidentID: 9999})
+			return err
+		},
+		"deleteIncidentRelation": func() error {
+			_, err := m.DeleteIncidentRelation(ctx, missing)
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+	}
+
+	if n := countRows(t, db, &models.Response{}); n != 0 {
+		t.Errorf("responses = %d, want 0", n)
+	}
+	if n := countRows(t, db, &models.IncidentRelation{}); n != 0 {
+		t.Errorf("incident relations = %d, want 0", n)
+	}
+}
+
+func TestUpdateResponseRejectsUnknownIncident(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	m := r.Mutation()
+	ctx := context.Background()
+
+	incident := createTestIncident(t, r)
+	input := models.ResponseInput{IncidentID: incident.ID, DateTime: "2024-04-01T10:00:00Z", Responder: "yamada", Content: "調査開始"}
+	response, err := m.CreateResponse(ctx, input)
+	if err != nil {
+		t.Fatalf("CreateResponse: %v", err)
+	}
+
+	input.IncidentID = 9999
+	if _, err := m.UpdateResponse(ctx, formatID(response.ID), input); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("UpdateResponse: got %v, want ErrNotFound", err)
+	}
+
+	var stored models.Response
+	if err := r.DB.First(&stored, response.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.IncidentID != incident.ID {
+		t.Errorf("response moved to incident %d, want %d", stored.IncidentID, incident.ID)
+	}
+}
+
+func TestCreateIncidentRelationRejectsSelfRelation(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	incident := createTestIncident(t, r)
+
+	_, err := r.Mutation().CreateIncidentRelation(context.Background(), models.IncidentRelationInput{
+		IncidentID:        incident.ID,
+		RelatedIncidentID: incident.ID,
+	})
+	if !errors.Is(err, ErrSelfRelation) {
+		t.Fatalf("got %v, want ErrSelfRelation", err)
+	}
+	if n := countRows(t, db, &models.IncidentRelation{}); n != 0 {
+		t.Errorf("incident relations = %d, want 0", n)
+	}
+}
+
+func TestCreateIncidentRelationRejectsDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	m := r.Mutation()
+	ctx := context.Background()
+
+	a := createTestIncident(t, r)
+	b := createTestIncident(t, r)
+
+	relation, err := m.CreateIncidentRelation(ctx, models.IncidentRelationInput{IncidentID: a.ID, RelatedIncidentID: b.ID})
+	if err != nil {
+		t.Fatalf("CreateIncidentRelation: %v", err)
+	}
+	if relation.Incident.ID != a.ID || relation.RelatedIncident.ID != b.ID {
+		t.Errorf("relation incidents = %d -> %d, want %d -> %d", relation.Incident.ID, relation.RelatedIncident.ID, a.ID, b.ID)
+	}
+
+	// 同じ組み合わせは向きを問わず重複として扱う
+	for _, input := range []models.IncidentRelationInput{
+		{IncidentID: a.ID, RelatedIncidentID: b.ID},
+		{IncidentID: b.ID, RelatedIncidentID: a.ID},
+	} {
+		if _, err := m.CreateIncidentRelation(ctx, input); !errors.Is(err, ErrDuplicateRelation) {
+			t.Errorf("CreateIncidentRelation(%d -> %d): got %v, want ErrDuplicateRelation", input.IncidentID, input.RelatedIncidentID, err)
+		}
+	}
+
+	if n := countRows(t, db, &models.IncidentRelation{}); n != 1 {
+		t.Errorf("incident relations = %d, want 1", n)
+	}
+}
+
+func TestDeleteIncidentRemovesDependents(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	m := r.Mutation()
+	ctx := context.Background()
+
+	a := createTestIncident(t, r)
+	b := createTestIncident(t, r)
+	if _, err := m.CreateResponse(ctx, models.ResponseInput{IncidentID: a.ID, DateTime: "2024-04-01T10:00:00Z", Responder: "yamada", Content: "調査開始"}); err != nil {
+		t.Fatalf("CreateResponse: %v", err)
+	}
+	if _, err := m.CreateIncidentRelation(ctx, models.IncidentRelationInput{IncidentID: b.ID, RelatedIncidentID: a.ID}); err != nil {
+		t.Fatalf("CreateIncidentRelation: %v", err)
+	}
+
+	if ok, err := m.DeleteIncident(ctx, formatID(a.ID)); err != nil || !ok {
+		t.Fatalf("DeleteIncident: %v, %v", ok, err)
+	}
+
+	if n := countRows(t, db, &models.Response{}); n != 0 {
+		t.Errorf("responses = %d, want 0", n)
+	}
+	if n := countRows(t, db, &models.IncidentRelation{}); n != 0 {
+		t.Errorf("incident relations = %d, want 0", n)
+	}
+	if n := countRows(t, db, &models.Incident{}); n != 1 {
+		t.Errorf("incidents = %d, want 1", n)
+	}
+}
+
+func TestDeleteIncidentRollsBackOnFailure(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	m := r.Mutation()
+	ctx := context.Background()
+
+	incident := createTestIncident(t, r)
+	if _, err := m.CreateResponse(ctx, models.ResponseInput{IncidentID: incident.ID, DateTime: "2024-04-01T10:00:00Z", Responder: "yamada", Content: "調査開始"}); err != nil {
+		t.Fatalf("CreateResponse: %v", err)
+	}
+
+	// 対応履歴を削除した後、インシデント本体の削除で失敗させる
+	failWrites(t, db, "incidents")
+
+	if _, err := m.DeleteIncident(ctx, formatID(incident.ID)); err == nil {
+		t.Fatal("DeleteIncident succeeded, want error")
+	}
+	if n := countRows(t, db, &models.Response{}); n != 1 {
+		t.Errorf("responses = %d, want 1 after rollback", n)
+	}
+	if n := countRows(t, db, &models.Incident{}); n != 1 {
+		t.Errorf("incidents = %d, want 1 after rollback", n)
+	}
+}
+
+func TestDeleteResponseAndRelation(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	m := r.Mutation()
+	ctx := context.Background()
+
+	a := createTestIncident(t, r)
+	b := createTestIncident(t, r)
+	response, err := m.CreateResponse(ctx, models.ResponseInput{IncidentID: a.ID, DateTime: "2024-04-01T10:00:00Z", Responder: "yamada", Content: "調査開始"})
+	if err != nil {
+		t.Fatalf("CreateResponse: %v", err)
+	}
+	relation, err := m.CreateIncidentRelation(ctx, models.IncidentRelationInput{IncidentID: a.ID, RelatedIncidentID: b.ID})
+	if err != nil {
+		t.Fatalf("CreateIncidentRelation: %v", err)
+	}
+
+	if ok, err := m.DeleteResponse(ctx, formatID(response.ID)); err != nil || !ok {
+		t.Errorf("DeleteResponse: %v, %v", ok, err)
+	}
+	if _, err := m.DeleteResponse(ctx, formatID(response.ID)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteResponse twice: got %v, want ErrNotFound", err)
+	}
+
+	if ok, err := m.DeleteIncidentRelation(ctx, formatID(relation.ID)); err != nil || !ok {
+		t.Errorf("DeleteIncidentRelation: %v, %v", ok, err)
+	}
+	if _, err := m.DeleteIncidentRelation(ctx, formatID(relation.ID)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteIncidentRelation twice: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,90 @@
+package resolvers
+
+import (
+	"context"
+	"dbpilot/internal/models"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB はテスト用に SQLite のデータベースを作成する。
+// 本番の Postgres 向けマイグレーションは使えないため、同じ制約を持つスキーマを直接作る。
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "dbpilot.db") + "?_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:  logger.Discard,
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	})
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get database instance: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&models.Incident{},
+		&models.Response{},
+		&models.IncidentRelation{},
+		&models.IncidentStatusHistory{},
+	); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+
+	// create_incident_relations_table の一意インデックスを SQLite の関数で再現する
+	if err := db.Exec(`
+        CREATE UNIQUE INDEX idx_incident_relations_unique_pair
+        ON incident_relations (MIN(incident_id, related_incident_id), MAX(incident_id, related_incident_id))
+        WHERE incident_id != related_incident_id
+    `).Error; err != nil {
+		t.Fatalf("failed to create unique pair index: %v", err)
+	}
+
+	return db
+}
+
+func newIncidentInput() models.IncidentInput {
+	return models.IncidentInput{
+		DateTime:  "2024-04-01T09:00:00Z",
+		Status:    models.IncidentStatusNew,
+		Judgment:  models.IncidentJudgmentActionRequired,
+		Content:   "メールサーバーの応答遅延",
+		Assignee:  "yamada",
+		Priority:  models.IncidentPriorityHigh,
+		FromEmail: "alert@example.com",
+		ToEmail:   "ops@example.com",
+		Subject:   "mail latency",
+	}
+}
+
+// createTestIncident はリゾルバーを通してインシデントを作成する
+func createTestIncident(t *testing.T, r *Resolver) *models.Incident {
+	t.Helper()
+
+	incident, err := r.Mutation().CreateIncident(context.Background(), newIncidentInput())
+	if err != nil {
+		t.Fatalf("CreateIncident: %v", err)
+	}
+	return incident
+}
+
+func countRows(t *testing.T, db *gorm.DB, model interface{}) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Model(model).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	return count
+}
@@ -0,0 +1,52 @@
+package resolvers
+
+import (
+	"context"
+	"dbpilot/internal/auth"
+	"dbpilot/internal/models"
+	"dbpilot/internal/workflow"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// workflow はインシデントの状態遷移の定義を返す。未設定の場合は既定のワークフローを使う。
+func (r *Resolver) workflow() *workflow.Workflow {
+	if r.Workflow != nil {
+		return r.Workflow
+	}
+	return workflow.Default()
+}
+
+// recordStatusChange は状態遷移の履歴を記録する。from が nil の場合は作成時の状態を表す。
+func recordStatusChange(ctx context.Context, tx *gorm.DB, incidentID uint, from *models.IncidentStatus, to models.IncidentStatus, comment *string) error {
+	if comment != nil && strings.TrimSpace(*comment) == "" {
+		comment = nil
+	}
+
+	history := models.IncidentStatusHistory{
+		IncidentID: incidentID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID(ctx),
+		Comment:    comment,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return fmt.Errorf("failed to record status history: %v", err)
+	}
+	return nil
+}
+
+// actorID はアクセストークンの sub クレームから操作したユーザーのIDを取り出す
+func actorID(ctx context.Context) *uint {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	id, err := claims.UserID()
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,205 @@
+package resolvers
+
+import (
+	"context"
+	"dbpilot/internal/models"
+	"dbpilot/internal/workflow"
+	"errors"
+	"testing"
+)
+
+// transition はインシデントの状態を遷移させる。失敗した場合はテストを中断する。
+func transition(t *testing.T, r *Resolver, incident *models.Incident, to models.IncidentStatus) {
+	t.Helper()
+
+	if _, err := r.Mutation().TransitionIncident(context.Background(), formatID(incident.ID), to, nil); err != nil {
+		t.Fatalf("TransitionIncident(%s): %v", to, err)
+	}
+}
+
+// statusHistory はインシデントの状態遷移の履歴を古い順に返す
+func statusHistory(t *testing.T, r *Resolver, incidentID uint) []models.IncidentStatusHistory {
+	t.Helper()
+
+	var histories []models.IncidentStatusHistory
+	if err := r.DB.Where("incident_id = ?", incidentID).Order("id").Find(&histories).Error; err != nil {
+		t.Fatal(err)
+	}
+	return histories
+}
+
+func TestCreateIncidentRequiresInitialStatus(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+
+	for _, status := range []models.IncidentStatus{models.IncidentStatusResolved, models.IncidentStatusClosed} {
+		input := newIncidentInput()
+		input.Status = status
+		if _, err := r.Mutation().CreateIncident(context.Background(), input); !errors.Is(err, workflow.ErrTransitionNotAllowed) {
+			t.Errorf("CreateIncident(%s) err = %v, want ErrTransitionNotAllowed", status, err)
+		}
+	}
+	if n := countRows(t, db, &models.Incident{}); n != 0 {
+		t.Errorf("incidents = %d, want 0", n)
+	}
+
+	// 作成時の状態はワークフローの定義に従う
+	custom, err := workflow.Parse(">new,>triaged,new>triaged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Workflow = custom
+	input := newIncidentInput()
+	input.Status = models.IncidentStatusTriaged
+	incident, err := r.Mutation().CreateIncident(context.Background(), input)
+	if err != nil {
+		t.Fatalf("CreateIncident(triaged): %v", err)
+	}
+
+	histories := statusHistory(t, r, incident.ID)
+	if len(histories) != 1 || histories[0].FromStatus != nil || histories[0].ToStatus != models.IncidentStatusTriaged {
+		t.Errorf("history = %+v, want one initial entry for triaged", histories)
+	}
+}
+
+func TestTransitionIncidentRecordsHistory(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	incident := createTestIncident(t, r)
+
+	comment := "原因を特定"
+	updated, err := r.Mutation().TransitionIncident(context.Background(), formatID(incident.ID), models.IncidentStatusTriaged, &comment)
+	if err != nil {
+		t.Fatalf("TransitionIncident: %v", err)
+	}
+	if updated.Status != models.IncidentStatusTriaged {
+		t.Errorf("status = %s, want %s", updated.Status, models.IncidentStatusTriaged)
+	}
+
+	histories := statusHistory(t, r, incident.ID)
+	if len(histories) != 2 {
+		t.Fatalf("histories = %d, want 2", len(histories))
+	}
+	last := histories[1]
+	if last.FromStatus == nil || *last.FromStatus != models.IncidentStatusNew || last.ToStatus != models.IncidentStatusTriaged {
+		t.Errorf("history = %v -> %s, want new -> triaged", last.FromStatus, last.ToStatus)
+	}
+	if last.Comment == nil || *last.Comment != comment {
+		t.Errorf("comment = %v, want %q", last.Comment, comment)
+	}
+}
+
+func TestTransitionIncidentRejectsClosedToNew(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	incident := createTestIncident(t, r)
+	for _, to := range []models.IncidentStatus{
+		models.IncidentStatusTriaged,
+		models.IncidentStatusInProgress,
+		models.IncidentStatusResolved,
+		models.IncidentStatusClosed,
+	} {
+		transition(t, r, incident, to)
+	}
+
+	_, err := r.Mutation().TransitionIncident(context.Background(), formatID(incident.ID), models.IncidentStatusNew, nil)
+	if !errors.Is(err, workflow.ErrTransitionNotAllowed) {
+		t.Fatalf("err = %v, want ErrTransitionNotAllowed", err)
+	}
+
+	var stored models.Incident
+	if err := r.DB.First(&stored, incident.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.IncidentStatusClosed {
+		t.Errorf("status = %s, want %s", stored.Status, models.IncidentStatusClosed)
+	}
+	if n := len(statusHistory(t, r, incident.ID)); n != 5 {
+		t.Errorf("histories = %d, want 5", n)
+	}
+}
+
+func TestTransitionIncidentAllowsReopen(t *testing.T) {
+	r := &Resolver{DB: newTestDB(t)}
+	incident := createTestIncident(t, r)
+	for _, to := range []models.IncidentStatus{
+		models.IncidentStatusTriaged,
+		models.IncidentStatusInProgress,
+		models.IncidentStatusResolved,
+		models.IncidentStatusClosed,
+		models.IncidentStatusInProgress,
+	} {
+		transition(t, r, incident, to)
+	}
+
+	histories := statusHistory(t, r, incident.ID)
+	last := histories[len(histories)-1]
+	if last.FromStatus == nil || *last.FromStatus != models.IncidentStatusClosed || last.ToStatus != models.IncidentStatusInProgress {
+		t.Errorf("history = %v -> %s, want closed -> in_progress", last.FromStatus, last.ToStatus)
+	}
+}
+
+func TestUpdateIncidentRejectsDisallowedStatusChange(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	incident := createTestIncident(t, r)
+
+	input := newIncidentInput()
+	input.Status = models.IncidentStatusClosed
+	input.Subject = "updated"
+	if _, err := r.Mutation().UpdateIncident(context.Background(), formatID(incident.ID), input); !errors.Is(err, workflow.ErrTransitionNotAllowed) {
+		t.Fatalf("err = %v, want ErrTransitionNotAllowed", err)
+	}
+
+	var stored models.Incident
+	if err := db.First(&stored, incident.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.IncidentStatusNew || stored.Subject != incident.Subject {
+		t.Errorf("incident = %s/%q, want unchanged %s/%q", stored.Status, stored.Subject, models.IncidentStatusNew, incident.Subject)
+	}
+
+	// 状態を変えない更新は履歴を残さない
+	input.Status = models.IncidentStatusNew
+	if _, err := r.Mutation().UpdateIncident(context.Background(), formatID(incident.ID), input); err != nil {
+		t.Fatalf("UpdateIncident: %v", err)
+	}
+	if n := len(statusHistory(t, r, incident.ID)); n != 1 {
+		t.Errorf("histories = %d, want 1", n)
+	}
+}
+
+func TestCreateIncidentRollsBackWhenHistoryFails(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+
+	failWrites(t, db, "incident_status_histories")
+
+	if _, err := r.Mutation().CreateIncident(context.Background(), newIncidentInput()); err == nil {
+		t.Fatal("CreateIncident succeeded, want error")
+	}
+	if n := countRows(t, db, &models.Incident{}); n != 0 {
+		t.Errorf("incidents = %d, want 0 after rollback", n)
+	}
+}
+
+func TestUpdateIncidentRollsBackWhenHistoryFails(t *testing.T) {
+	db := newTestDB(t)
+	r := &Resolver{DB: db}
+	incident := createTestIncident(t, r)
+
+	failWrites(t, db, "incident_status_histories")
+
+	input := newIncidentInput()
+	input.Status = models.IncidentStatusTriaged
+	input.Subject = "updated"
+	if _, err := r.Mutation().UpdateIncident(context.Background(), formatID(incident.ID), input); err == nil {
+		t.Fatal("UpdateIncident succeeded, want error")
+	}
+
+	var stored models.Incident
+	if err := db.First(&stored, incident.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.IncidentStatusNew || stored.Subject != incident.Subject {
+		t.Errorf("incident = %s/%q, want unchanged %s/%q", stored.Status, stored.Subject, models.IncidentStatusNew, incident.Subject)
+	}
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// IncidentOrderField はインシデント一覧を並べ替えるキー
+type IncidentOrderField string
+
+const (
+	IncidentOrderFieldDatetime  IncidentOrderField = "DATETIME"
+	IncidentOrderFieldCreatedAt IncidentOrderField = "CREATED_AT"
+	IncidentOrderFieldUpdatedAt IncidentOrderField = "UPDATED_AT"
+	IncidentOrderFieldID        IncidentOrderField = "ID"
+)
+
+// Column は並べ替えキーに対応するカラム名を返す
+func (e IncidentOrderField) Column() string {
+	switch e {
+	case IncidentOrderFieldCreatedAt:
+		return "created_at"
+	case IncidentOrderFieldUpdatedAt:
+		return "updated_at"
+	case IncidentOrderFieldID:
+		return "id"
+	default:
+		return "date_time"
+	}
+}
+
+func (e IncidentOrderField) IsValid() bool {
+	switch e {
+	case IncidentOrderFieldDatetime, IncidentOrderFieldCreatedAt, IncidentOrderFieldUpdatedAt, IncidentOrderFieldID:
+		return true
+	}
+	return false
+}
+
+func (e IncidentOrderField) String() string {
+	return string(e)
+}
+
+func (e *IncidentOrderField) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = IncidentOrderField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IncidentOrderField", str)
+	}
+	return nil
+}
+
+func (e IncidentOrderField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// OrderDirection は並び順の向き
+type OrderDirection string
+
+const (
+	OrderDirectionAsc  OrderDirection = "ASC"
+	OrderDirectionDesc OrderDirection = "DESC"
+)
+
+func (e OrderDirection) IsValid() bool {
+	switch e {
+	case OrderDirectionAsc, OrderDirectionDesc:
+		return true
+	}
+	return false
+}
+
+func (e OrderDirection) String() string {
+	return string(e)
+}
+
+func (e *OrderDirection) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderDirection(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderDirection", str)
+	}
+	return nil
+}
+
+func (e OrderDirection) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// PageInfo は Relay 形式のページ情報を表す構造体
+type PageInfo struct {
+	HasNextPage     bool    `json:"has_next_page"`
+	HasPreviousPage bool    `json:"has_previous_page"`
+	StartCursor     *string `json:"start_cursor"`
+	EndCursor       *string `json:"end_cursor"`
+}
+
+// IncidentEdge はカーソル付きのインシデントを表す構造体
+type IncidentEdge struct {
+	Cursor string    `json:"cursor"`
+	Node   *Incident `json:"node"`
+}
+
+// IncidentConnection はインシデント一覧の1ページを表す構造体。
+// totalCount は要求された場合のみ Filter を使って集計する。
+type IncidentConnection struct {
+	Edges    []*IncidentEdge `json:"edges"`
+	PageInfo *PageInfo       `json:"page_info"`
+
+	Filter *IncidentFilter `json:"-"`
+}
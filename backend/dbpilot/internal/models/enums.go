@@ -0,0 +1,216 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// インシデントの列挙値。DB には小文字の値を保存し、GraphQL では大文字の列挙名として公開する。
+
+// IncidentStatus はインシデントの対応状況
+type IncidentStatus string
+
+const (
+	IncidentStatusNew        IncidentStatus = "new"
+	IncidentStatusTriaged    IncidentStatus = "triaged"
+	IncidentStatusInProgress IncidentStatus = "in_progress"
+	IncidentStatusResolved   IncidentStatus = "resolved"
+	IncidentStatusClosed     IncidentStatus = "closed"
+)
+
+var AllIncidentStatuses = []IncidentStatus{
+	IncidentStatusNew,
+	IncidentStatusTriaged,
+	IncidentStatusInProgress,
+	IncidentStatusResolved,
+	IncidentStatusClosed,
+}
+
+// incidentStatusAliases は列挙型の導入前に保存されていた値との対応
+var incidentStatusAliases = map[string]IncidentStatus{
+	"未着手":           IncidentStatusNew,
+	"open":          IncidentStatusNew,
+	"トリアージ済み":       IncidentStatusTriaged,
+	"調査中":           IncidentStatusInProgress,
+	"対応中":           IncidentStatusInProgress,
+	"in progress":   IncidentStatusInProgress,
+	"inprogress":    IncidentStatusInProgress,
+	"investigating": IncidentStatusInProgress,
+	"解決済み":          IncidentStatusResolved,
+	"解決":            IncidentStatusResolved,
+	"クローズ":          IncidentStatusClosed,
+	"完了":            IncidentStatusClosed,
+}
+
+func (e IncidentStatus) IsValid() bool {
+	for _, v := range AllIncidentStatuses {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (e IncidentStatus) String() string {
+	return string(e)
+}
+
+func (e *IncidentStatus) UnmarshalGQL(v interface{}) error {
+	return unmarshalEnum(v, "IncidentStatus", func(s string) bool {
+		*e = IncidentStatus(s)
+		return e.IsValid()
+	})
+}
+
+func (e IncidentStatus) MarshalGQL(w io.Writer) {
+	marshalEnum(w, string(e))
+}
+
+// ParseIncidentStatus は表記揺れのある値を IncidentStatus に変換する
+func ParseIncidentStatus(s string) (IncidentStatus, bool) {
+	key := normalizeEnumValue(s)
+	if v := IncidentStatus(key); v.IsValid() {
+		return v, true
+	}
+	v, ok := incidentStatusAliases[key]
+	return v, ok
+}
+
+// IncidentPriority はインシデントの優先度
+type IncidentPriority string
+
+const (
+	IncidentPriorityHigh   IncidentPriority = "high"
+	IncidentPriorityMedium IncidentPriority = "medium"
+	IncidentPriorityLow    IncidentPriority = "low"
+)
+
+var AllIncidentPriorities = []IncidentPriority{
+	IncidentPriorityHigh,
+	IncidentPriorityMedium,
+	IncidentPriorityLow,
+}
+
+var incidentPriorityAliases = map[string]IncidentPriority{
+	"高":        IncidentPriorityHigh,
+	"p1":       IncidentPriorityHigh,
+	"critical": IncidentPriorityHigh,
+	"urgent":   IncidentPriorityHigh,
+	"中":        IncidentPriorityMedium,
+	"p2":       IncidentPriorityMedium,
+	"normal":   IncidentPriorityMedium,
+	"mid":      IncidentPriorityMedium,
+	"低":        IncidentPriorityLow,
+	"p3":       IncidentPriorityLow,
+	"p4":       IncidentPriorityLow,
+}
+
+func (e IncidentPriority) IsValid() bool {
+	for _, v := range AllIncidentPriorities {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (e IncidentPriority) String() string {
+	return string(e)
+}
+
+func (e *IncidentPriority) UnmarshalGQL(v interface{}) error {
+	return unmarshalEnum(v, "IncidentPriority", func(s string) bool {
+		*e = IncidentPriority(s)
+		return e.IsValid()
+	})
+}
+
+func (e IncidentPriority) MarshalGQL(w io.Writer) {
+	marshalEnum(w, string(e))
+}
+
+// ParseIncidentPriority は表記揺れのある値を IncidentPriority に変換する
+func ParseIncidentPriority(s string) (IncidentPriority, bool) {
+	key := normalizeEnumValue(s)
+	if v := IncidentPriority(key); v.IsValid() {
+		return v, true
+	}
+	v, ok := incidentPriorityAliases[key]
+	return v, ok
+}
+
+// IncidentJudgment はインシデントに対応が必要かどうかの判断
+type IncidentJudgment string
+
+const (
+	IncidentJudgmentActionRequired IncidentJudgment = "action_required"
+	IncidentJudgmentMonitor        IncidentJudgment = "monitor"
+)
+
+var AllIncidentJudgments = []IncidentJudgment{
+	IncidentJudgmentActionRequired,
+	IncidentJudgmentMonitor,
+}
+
+var incidentJudgmentAliases = map[string]IncidentJudgment{
+	"要対応":             IncidentJudgmentActionRequired,
+	"action required": IncidentJudgmentActionRequired,
+	"静観":              IncidentJudgmentMonitor,
+	"watch":           IncidentJudgmentMonitor,
+}
+
+func (e IncidentJudgment) IsValid() bool {
+	for _, v := range AllIncidentJudgments {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (e IncidentJudgment) String() string {
+	return string(e)
+}
+
+func (e *IncidentJudgment) UnmarshalGQL(v interface{}) error {
+	return unmarshalEnum(v, "IncidentJudgment", func(s string) bool {
+		*e = IncidentJudgment(s)
+		return e.IsValid()
+	})
+}
+
+func (e IncidentJudgment) MarshalGQL(w io.Writer) {
+	marshalEnum(w, string(e))
+}
+
+// ParseIncidentJudgment は表記揺れのある値を IncidentJudgment に変換する
+func ParseIncidentJudgment(s string) (IncidentJudgment, bool) {
+	key := normalizeEnumValue(s)
+	if v := IncidentJudgment(key); v.IsValid() {
+		return v, true
+	}
+	v, ok := incidentJudgmentAliases[key]
+	return v, ok
+}
+
+// unmarshalEnum は GraphQL の列挙名（IN_PROGRESS など）を DB の値に変換して検証する
+func unmarshalEnum(v interface{}, name string, set func(string) bool) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+	if !set(strings.ToLower(str)) {
+		return fmt.Errorf("%s is not a valid %s", str, name)
+	}
+	return nil
+}
+
+func marshalEnum(w io.Writer, value string) {
+	fmt.Fprint(w, strconv.Quote(strings.ToUpper(value)))
+}
+
+func normalizeEnumValue(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
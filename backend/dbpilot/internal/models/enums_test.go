@@ -0,0 +1,55 @@
+package models
+
+import "testing"
+
+func TestParseIncidentPriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want IncidentPriority
+		ok   bool
+	}{
+		{"high", IncidentPriorityHigh, true},
+		{"High", IncidentPriorityHigh, true},
+		{" HIGH ", IncidentPriorityHigh, true},
+		{"P1", IncidentPriorityHigh, true},
+		{"高", IncidentPriorityHigh, true},
+		{"normal", IncidentPriorityMedium, true},
+		{"p4", IncidentPriorityLow, true},
+		{"p5", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseIncidentPriority(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseIncidentPriority(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseIncidentStatusAndJudgment(t *testing.T) {
+	statuses := map[string]IncidentStatus{
+		"in_progress": IncidentStatusInProgress,
+		"In Progress": IncidentStatusInProgress,
+		"対応中":         IncidentStatusInProgress,
+		"Open":        IncidentStatusNew,
+	}
+	for in, want := range statuses {
+		if got, ok := ParseIncidentStatus(in); !ok || got != want {
+			t.Errorf("ParseIncidentStatus(%q) = %q, %v, want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseIncidentStatus("pending"); ok {
+		t.Error(`ParseIncidentStatus("pending") succeeded, want failure`)
+	}
+
+	judgments := map[string]IncidentJudgment{
+		"Action Required": IncidentJudgmentActionRequired,
+		"要対応":             IncidentJudgmentActionRequired,
+		"watch":           IncidentJudgmentMonitor,
+	}
+	for in, want := range judgments {
+		if got, ok := ParseIncidentJudgment(in); !ok || got != want {
+			t.Errorf("ParseIncidentJudgment(%q) = %q, %v, want %q", in, got, ok, want)
+		}
+	}
+}
@@ -1,16 +1,59 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrInvalidInput = errors.New("invalid input")
+
 // IncidentInput はインシデント作成/更新時の入力データを表す構造体
 type IncidentInput struct {
-	DateTime  string `json:"datetime"`
-	Status    string `json:"status"`
-	Judgment  string `json:"judgment"`
-	Content   string `json:"content"`
-	Assignee  string `json:"assignee"`
-	Priority  string `json:"priority"`
-	FromEmail string `json:"from_email"`
-	ToEmail   string `json:"to_email"`
-	Subject   string `json:"subject"`
+	DateTime  string           `json:"datetime"`
+	Status    IncidentStatus   `json:"status"`
+	Judgment  IncidentJudgment `json:"judgment"`
+	Content   string           `json:"content"`
+	Assignee  string           `json:"assignee"`
+	Priority  IncidentPriority `json:"priority"`
+	FromEmail string           `json:"from_email"`
+	ToEmail   string           `json:"to_email"`
+	Subject   string           `json:"subject"`
+}
+
+// Validate は列挙値と、カラムの制約に合わせた文字列の長さを検証する
+func (in IncidentInput) Validate() error {
+	if !in.Status.IsValid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidInput, in.Status)
+	}
+	if !in.Priority.IsValid() {
+		return fmt.Errorf("%w: unknown priority %q", ErrInvalidInput, in.Priority)
+	}
+	if !in.Judgment.IsValid() {
+		return fmt.Errorf("%w: unknown judgment %q", ErrInvalidInput, in.Judgment)
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"content", in.Content, 0},
+		{"assignee", in.Assignee, 100},
+		{"fromEmail", in.FromEmail, 100},
+		{"toEmail", in.ToEmail, 100},
+		{"subject", in.Subject, 200},
+	}
+	for _, f := range fields {
+		if strings.TrimSpace(f.value) == "" {
+			return fmt.Errorf("%w: %s must not be empty", ErrInvalidInput, f.name)
+		}
+		if f.max > 0 && utf8.RuneCountInString(f.value) > f.max {
+			return fmt.Errorf("%w: %s must be at most %d characters", ErrInvalidInput, f.name, f.max)
+		}
+	}
+	return nil
 }
 
 // ResponseInput は対応履歴作成時の入力データを表す構造体
@@ -26,3 +69,21 @@ type IncidentRelationInput struct {
 	IncidentID        uint `json:"incident_id"`
 	RelatedIncidentID uint `json:"related_incident_id"`
 }
+
+// IncidentFilter はインシデント一覧の絞り込み条件を表す構造体
+type IncidentFilter struct {
+	Status       []IncidentStatus   `json:"status"`
+	Priority     []IncidentPriority `json:"priority"`
+	Judgment     []IncidentJudgment `json:"judgment"`
+	Assignee     *string            `json:"assignee"`
+	DatetimeFrom *string            `json:"datetime_from"`
+	DatetimeTo   *string            `json:"datetime_to"`
+	FromEmail    *string            `json:"from_email"`
+	ToEmail      *string            `json:"to_email"`
+}
+
+// IncidentOrder はインシデント一覧の並び順を表す構造体
+type IncidentOrder struct {
+	Field     IncidentOrderField `json:"field"`
+	Direction OrderDirection     `json:"direction"`
+}
@@ -6,16 +6,16 @@ import (
 
 // Incident はインシデント情報を表す構造体
 type Incident struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	DateTime  time.Time `json:"datetime"`
-	Status    string    `gorm:"size:50;not null" json:"status"`
-	Judgment  string    `gorm:"size:50;not null" json:"judgment"`
-	Content   string    `gorm:"type:text;not null" json:"content"`
-	Assignee  string    `gorm:"size:100;not null" json:"assignee"`
-	Priority  string    `gorm:"size:10;not null" json:"priority"`
-	FromEmail string    `gorm:"size:100;not null" json:"from_email"`
-	ToEmail   string    `gorm:"size:100;not null" json:"to_email"`
-	Subject   string    `gorm:"size:200;not null" json:"subject"`
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	DateTime  time.Time        `json:"datetime"`
+	Status    IncidentStatus   `gorm:"size:50;not null" json:"status"`
+	Judgment  IncidentJudgment `gorm:"size:50;not null" json:"judgment"`
+	Content   string           `gorm:"type:text;not null" json:"content"`
+	Assignee  string           `gorm:"size:100;not null" json:"assignee"`
+	Priority  IncidentPriority `gorm:"size:10;not null" json:"priority"`
+	FromEmail string           `gorm:"size:100;not null" json:"from_email"`
+	ToEmail   string           `gorm:"size:100;not null" json:"to_email"`
+	Subject   string           `gorm:"size:200;not null" json:"subject"`
 
 	// 1:N関係 - インシデントと対応履歴
 	Responses []Response `gorm:"foreignKey:IncidentID;constraint:OnDelete:CASCADE" json:"responses,omitempty"`
@@ -57,3 +57,18 @@ type IncidentRelation struct {
 	CreatedAt time.Time `gorm:"not null" json:"created_at"`
 	UpdatedAt time.Time `gorm:"not null" json:"updated_at"`
 }
+
+// IncidentStatusHistory はインシデントの状態遷移の履歴を表す構造体
+type IncidentStatusHistory struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	IncidentID uint            `gorm:"not null;index" json:"incident_id"`
+	FromStatus *IncidentStatus `gorm:"size:50" json:"from_status"`
+	ToStatus   IncidentStatus  `gorm:"size:50;not null" json:"to_status"`
+	ActorID    *uint           `json:"actor_id"`
+	Comment    *string         `gorm:"type:text" json:"comment"`
+
+	// N:1関係 - 状態遷移とインシデント
+	Incident Incident `gorm:"foreignKey:IncidentID;constraint:OnDelete:CASCADE" json:"-"`
+
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+}
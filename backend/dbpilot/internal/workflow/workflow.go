@@ -0,0 +1,93 @@
+package workflow
+
+import (
+	"dbpilot/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrTransitionNotAllowed = errors.New("status transition is not allowed")
+
+// DefaultSpec は既定のワークフロー。インシデントは new で作成し、
+// 解決済み・クローズ済みのインシデントは対応中に戻せる。
+const DefaultSpec = ">new,new>triaged,triaged>in_progress,in_progress>resolved,resolved>closed,resolved>in_progress,closed>in_progress"
+
+// Workflow はインシデントの状態遷移として許可する組み合わせを保持する。
+// 遷移元が空の遷移は、インシデントを作成するときに指定できる状態を表す。
+type Workflow struct {
+	transitions map[models.IncidentStatus][]models.IncidentStatus
+}
+
+// Parse は "from>to" をカンマで区切った定義からワークフローを作成する。
+// ">to" は作成時の状態として to を許可する。作成時の状態が一つもない定義は受け付けない。
+func Parse(spec string) (*Workflow, error) {
+	w := &Workflow{transitions: make(map[models.IncidentStatus][]models.IncidentStatus)}
+
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(pair, ">")
+		if !ok {
+			return nil, fmt.Errorf("invalid transition %q: expected from>to", pair)
+		}
+		fromStatus := models.IncidentStatus(strings.TrimSpace(from))
+		toStatus := models.IncidentStatus(strings.TrimSpace(to))
+		if (fromStatus != "" && !fromStatus.IsValid()) || !toStatus.IsValid() {
+			return nil, fmt.Errorf("invalid transition %q: unknown status", pair)
+		}
+		if fromStatus == toStatus {
+			return nil, fmt.Errorf("invalid transition %q: from and to are the same", pair)
+		}
+		if !w.Allows(fromStatus, toStatus) {
+			w.transitions[fromStatus] = append(w.transitions[fromStatus], toStatus)
+		}
+	}
+
+	if len(w.transitions) == 0 {
+		return nil, errors.New("workflow has no transitions")
+	}
+	if len(w.transitions[""]) == 0 {
+		return nil, errors.New("workflow has no initial status: add \">status\"")
+	}
+	return w, nil
+}
+
+// Default は既定のワークフローを返す
+func Default() *Workflow {
+	w, err := Parse(DefaultSpec)
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// Allows は from から to への遷移が許可されているか判定する
+func (w *Workflow) Allows(from, to models.IncidentStatus) bool {
+	for _, next := range w.transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Next は from から遷移できる状態を返す
+func (w *Workflow) Next(from models.IncidentStatus) []models.IncidentStatus {
+	return w.transitions[from]
+}
+
+// Check は遷移が許可されていなければ ErrTransitionNotAllowed をラップしたエラーを返す。
+// from が空の場合は作成時の状態として許可されているかを判定する。
+func (w *Workflow) Check(from, to models.IncidentStatus) error {
+	if w.Allows(from, to) {
+		return nil
+	}
+	if from == "" {
+		return fmt.Errorf("%w: incidents cannot be created as %s", ErrTransitionNotAllowed, to)
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrTransitionNotAllowed, from, to)
+}
@@ -0,0 +1,76 @@
+package workflow
+
+import (
+	"dbpilot/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	w, err := Parse(" >new , new>triaged,triaged>closed,new>triaged")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if !w.Allows(models.IncidentStatusNew, models.IncidentStatusTriaged) {
+		t.Error("new -> triaged should be allowed")
+	}
+	if w.Allows(models.IncidentStatusTriaged, models.IncidentStatusNew) {
+		t.Error("transitions must not be allowed in reverse")
+	}
+	if got := w.Next(models.IncidentStatusNew); len(got) != 1 {
+		t.Errorf("Next(new) = %v, want the duplicate removed", got)
+	}
+	if got := w.Next(""); len(got) != 1 || got[0] != models.IncidentStatusNew {
+		t.Errorf("initial statuses = %v, want [new]", got)
+	}
+}
+
+func TestParseRejectsInvalidSpecs(t *testing.T) {
+	specs := map[string]string{
+		"empty":          "",
+		"missing arrow":  ">new,new-triaged",
+		"unknown status": ">new,new>done",
+		"unknown from":   ">new,done>new",
+		"self":           ">new,new>new",
+		"no initial":     "new>triaged",
+		"initial only":   ",",
+	}
+	for name, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("%s: Parse(%q) succeeded, want error", name, spec)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	w := Default()
+
+	allowed := [][2]models.IncidentStatus{
+		{"", models.IncidentStatusNew},
+		{models.IncidentStatusNew, models.IncidentStatusTriaged},
+		{models.IncidentStatusTriaged, models.IncidentStatusInProgress},
+		{models.IncidentStatusInProgress, models.IncidentStatusResolved},
+		{models.IncidentStatusResolved, models.IncidentStatusClosed},
+		{models.IncidentStatusResolved, models.IncidentStatusInProgress},
+		{models.IncidentStatusClosed, models.IncidentStatusInProgress},
+	}
+	for _, tr := range allowed {
+		if err := w.Check(tr[0], tr[1]); err != nil {
+			t.Errorf("Check(%q, %q): %v", tr[0], tr[1], err)
+		}
+	}
+
+	rejected := [][2]models.IncidentStatus{
+		{"", models.IncidentStatusClosed},
+		{"", models.IncidentStatusResolved},
+		{models.IncidentStatusClosed, models.IncidentStatusNew},
+		{models.IncidentStatusNew, models.IncidentStatusClosed},
+		{models.IncidentStatusResolved, models.IncidentStatusNew},
+	}
+	for _, tr := range rejected {
+		if err := w.Check(tr[0], tr[1]); !errors.Is(err, ErrTransitionNotAllowed) {
+			t.Errorf("Check(%q, %q) = %v, want ErrTransitionNotAllowed", tr[0], tr[1], err)
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"dbpilot/internal/auth"
 	"dbpilot/internal/config"
 	"dbpilot/internal/database"
 	"dbpilot/internal/database/migrations"
 	"dbpilot/internal/graphql/generated"
+	"dbpilot/internal/graphql/loaders"
 	"dbpilot/internal/graphql/resolvers"
+	"dbpilot/internal/workflow"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
@@ -17,6 +21,9 @@ import (
 func graphqlHandler(r *resolvers.Resolver) gin.HandlerFunc {
 	config := generated.Config{
 		Resolvers: r,
+		Directives: generated.DirectiveRoot{
+			HasPermission: auth.HasPermissionDirective,
+		},
 	}
 	h := handler.NewDefaultServer(generated.NewExecutableSchema(config))
 
@@ -61,16 +68,28 @@ func main() {
 		}
 	}
 
+	// インシデントの状態遷移の定義（INCIDENT_WORKFLOW 未設定時は既定のワークフロー）
+	incidentWorkflow := workflow.Default()
+	if cfg.IncidentWorkflow != "" {
+		if incidentWorkflow, err = workflow.Parse(cfg.IncidentWorkflow); err != nil {
+			log.Fatalf("Invalid INCIDENT_WORKFLOW: %v", err)
+		}
+	}
+
 	// Initialize resolver with database connection
 	resolver := &resolvers.Resolver{
-		DB: database.DB,
+		DB:       database.DB,
+		Workflow: incidentWorkflow,
 	}
 
 	// Initialize Gin router
 	r := gin.Default()
 
+	// アクセストークンの検証（認証サービスの JWKS を使用）
+	verifier := auth.NewVerifier(auth.NewJWKSCache(cfg.AuthJWKSURL), cfg.JWTIssuer, cfg.JWTAudience, 30*time.Second)
+
 	// GraphQL endpoints
-	r.POST("/query", graphqlHandler(resolver))
+	r.POST("/query", auth.Middleware(verifier), loaders.Middleware(database.DB), graphqlHandler(resolver))
 	r.GET("/playground", playgroundHandler())
 
 	// Start server
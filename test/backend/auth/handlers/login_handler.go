@@ -2,15 +2,12 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
 	"net/http"
-	"os"
 	"time"
 
 	"auth/utils"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type LoginRequest struct {
@@ -18,10 +15,15 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-type QueryUserResponse struct {
-	ID       uint   `json:"id"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+type VerifyCredentialsResponse struct {
+	Match  bool   `json:"match"`
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type CreateSessionResponse struct {
+	ExpiresAt         time.Time `json:"expires_at"`
+	AbsoluteExpiresAt time.Time `json:"absolute_expires_at"`
 }
 
 func LoginUser(c *gin.Context) {
@@ -31,54 +33,72 @@ func LoginUser(c *gin.Context) {
 		return
 	}
 
-	// DB Pilot Serviceからユーザー情報を取得
-	baseURL := os.Getenv("DB_PILOT_SERVICE_URL")
-	userData := map[string]string{"email": req.Email}
-	userDataJSON, _ := json.Marshal(userData)
-	resp, err := http.Post(baseURL+"/queryUser", "application/json", bytes.NewBuffer(userDataJSON))
-	if err != nil || resp.StatusCode != http.StatusOK {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+	// パスワードの照合は DB Pilot Service で行い、一致したかどうかだけを受け取る
+	credentialsJSON, _ := json.Marshal(map[string]string{"email": req.Email, "password": req.Password})
+	resp, err := utils.PostDBPilot("/verify-credentials", credentialsJSON)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify credentials"})
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify credentials"})
 		return
 	}
 
-	var userResponse QueryUserResponse
+	var userResponse VerifyCredentialsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&userResponse); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user data"})
 		return
 	}
 
-	// パスワード検証
-	if err := bcrypt.CompareHashAndPassword([]byte(userResponse.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+	// ユーザーの有無を区別しない
+	if !userResponse.Match {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
 	}
 
-	// セッションIDの生成
+	// セッションIDと、セッションに結び付ける CSRF トークンの生成
 	sessionID := utils.GenerateSessionID()
-	expirationTime := time.Now().Add(24 * time.Hour) // セッションの有効期限
+	csrfToken, err := utils.GenerateCSRFToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CSRF token"})
+		return
+	}
 
-	// セッション情報をDB Pilot Serviceに保存
+	// セッション情報をDB Pilot Serviceに保存（有効期限は DB Pilot 側の設定で決まる）
 	saveSessionReq := map[string]interface{}{
-		"user_id":    userResponse.ID,
+		"user_id":    userResponse.UserID,
 		"email":      userResponse.Email,
 		"session_id": sessionID,
-		"expires_at": expirationTime,
+		"csrf_token": csrfToken,
+		"user_agent": c.Request.UserAgent(),
+		"ip":         c.ClientIP(),
 	}
 	saveSessionReqJSON, _ := json.Marshal(saveSessionReq)
-	_, err = http.Post(baseURL+"/create-session", "application/json", bytes.NewBuffer(saveSessionReqJSON))
+	sessionResp, err := utils.PostDBPilot("/create-session", saveSessionReqJSON)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
 		return
 	}
+	defer sessionResp.Body.Close()
+	if sessionResp.StatusCode != http.StatusOK {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+		return
+	}
+
+	var created CreateSessionResponse
+	if err := json.NewDecoder(sessionResp.Body).Decode(&created); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse session data"})
+		return
+	}
+
+	// セッションIDをHTTPOnlyクッキーとしてクライアントに返す。
+	// アイドル期限はサーバー側で延長されるため、クッキーは絶対期限まで保持させる。
+	http.SetCookie(c.Writer, utils.Cookies.SessionCookie(sessionID, created.AbsoluteExpiresAt))
 
-	// セッションIDをHTTPOnlyクッキーとしてクライアントに返す
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
-		HttpOnly: true,
-		Path:     "/",
-		Expires:  expirationTime,
-	})
+	// CSRF トークンはフロントエンドが読み取り、状態を変更するリクエストの X-CSRF-Token ヘッダーに付ける
+	http.SetCookie(c.Writer, utils.Cookies.CSRFCookie(csrfToken, created.AbsoluteExpiresAt))
 
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+	c.JSON(http.StatusOK, gin.H{"message": "Login successful", "csrf_token": csrfToken})
 }
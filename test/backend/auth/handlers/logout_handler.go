@@ -0,0 +1,76 @@
+// auth-service/handlers/logout_handler.go
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"auth/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type LogoutRequest struct {
+	// true の場合、同じユーザーの全ての端末のセッションを削除する
+	AllDevices bool `json:"all_devices"`
+}
+
+func LogoutUser(c *gin.Context) {
+	var req LogoutRequest
+	// ボディは省略可能
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+
+	sessionID, err := c.Cookie(utils.Cookies.SessionName)
+	if err != nil || sessionID == "" {
+		// セッションがなくてもクッキーは削除してログアウト済みとして扱う
+		clearSessionCookies(c)
+		c.JSON(http.StatusOK, gin.H{"message": "Logout successful"})
+		return
+	}
+
+	// DB Pilot Serviceでセッションを削除（CSRF トークンも DB Pilot 側で照合する）
+	deleteSessionReq := map[string]interface{}{
+		"session_id":  sessionID,
+		"csrf_token":  c.GetHeader("X-CSRF-Token"),
+		"all_devices": req.AllDevices,
+	}
+	deleteSessionReqJSON, _ := json.Marshal(deleteSessionReq)
+	resp, err := utils.PostDBPilot("/delete-session", deleteSessionReqJSON)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		// CSRF トークンの不一致はそのまま返す
+		c.Status(http.StatusForbidden)
+		c.Header("Content-Type", "application/json")
+		io.Copy(c.Writer, resp.Body)
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+		return
+	}
+
+	var deleted struct {
+		Deleted int64 `json:"deleted"`
+	}
+	json.NewDecoder(resp.Body).Decode(&deleted)
+
+	clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logout successful", "sessions_deleted": deleted.Deleted})
+}
+
+func clearSessionCookies(c *gin.Context) {
+	for _, cookie := range utils.Cookies.ClearCookies() {
+		http.SetCookie(c.Writer, cookie)
+	}
+}
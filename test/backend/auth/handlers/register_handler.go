@@ -2,13 +2,10 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
 	"net/http"
 
-	"os"
-
+	"auth/utils"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -33,15 +30,18 @@ func RegisterUser(c *gin.Context) {
 	}
 
 	// DB Pilot Serviceにハッシュ化済みパスワードを保存リクエスト
-	baseURL := os.Getenv("DB_PILOT_SERVICE_URL")
 	saveUserReq := map[string]string{
 		"email":    req.Email,
 		"password": string(hashedPassword),
 	}
 	saveUserReqJSON, _ := json.Marshal(saveUserReq)
-	fmt.Println(saveUserReqJSON)
-	resp, err := http.Post(baseURL+"/create-user", "application/json", bytes.NewBuffer(saveUserReqJSON))
-	if err != nil || resp.StatusCode != http.StatusOK {
+	resp, err := utils.PostDBPilot("/create-user", saveUserReqJSON)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save user to DB Pilot Service"})
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save user to DB Pilot Service"})
 		return
 	}
@@ -2,30 +2,73 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
-	"os"
+	"strings"
+	"time"
 
+	"auth/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// SessionPrincipal はセッションの検証結果として返す呼び出し元の情報
+type SessionPrincipal struct {
+	Active    bool      `json:"active"`
+	UserID    uint      `json:"user_id"`
+	Email     string    `json:"email"`
+	Roles     []string  `json:"roles"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// VerifySession はセッションクッキーまたは Bearer トークン（セッションID）を
+// DB Pilot Service のセッションストアで検証し、呼び出し元の情報を返す。
+// 他のサービスからはイントロスペクション API として利用できる。
 func VerifySession(c *gin.Context) {
-	tokenString := c.GetHeader("Authorization")
-	if tokenString == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token is required"})
+	sessionID := sessionIDFromRequest(c)
+	if sessionID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"active": false, "error": "Session is required"})
 		return
 	}
 
-	// トークンの解析と検証
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	introspectReq, _ := json.Marshal(map[string]string{"session_id": sessionID})
+	resp, err := utils.PostDBPilot("/introspect-session", introspectReq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+		return
+	}
+	defer resp.Body.Close()
 
-	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"active": false, "error": "Invalid session"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
 		return
 	}
 
-	// 有効なトークン
-	c.JSON(http.StatusOK, gin.H{"message": "Token is valid"})
+	var principal SessionPrincipal
+	if err := json.NewDecoder(resp.Body).Decode(&principal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse session data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, principal)
+}
+
+// sessionIDFromRequest は Authorization: Bearer ヘッダーを優先し、なければクッキーからセッションIDを取得する
+func sessionIDFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	sessionID, err := c.Cookie(utils.Cookies.SessionName)
+	if err != nil {
+		return ""
+	}
+	return sessionID
 }
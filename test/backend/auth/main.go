@@ -3,8 +3,10 @@ package main
 import (
 	"log"
 	"os"
+	"strings"
 
 	"auth/handlers"
+	"auth/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -15,11 +17,26 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	// DB Pilot Service への内部リクエストの署名に使う共有鍵
+	if os.Getenv("SERVICE_HMAC_SECRET") == "" {
+		log.Fatal("SERVICE_HMAC_SECRET is not set")
+	}
+
+	// クッキー属性の読み込み
+	utils.LoadCookieConfig()
+
 	r := gin.Default()
 
+	// X-Forwarded-For は TRUSTED_PROXIES（カンマ区切り）に含まれるプロキシからのものだけを信用する。
+	// 未設定の場合は接続元のアドレスをそのままクライアント IP とする。
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// エンドポイント設定
 	r.POST("/register", handlers.RegisterUser)
 	r.POST("/login", handlers.LoginUser)
+	r.POST("/logout", handlers.LogoutUser)
 	r.GET("/verify-session", handlers.VerifySession)
 
 	// サーバー起動
@@ -29,3 +46,14 @@ func main() {
 	}
 	r.Run(":" + serverPort)
 }
+
+// trustedProxies は TRUSTED_PROXIES に列挙されたアドレスを返す
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
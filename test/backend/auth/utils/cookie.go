@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const hostPrefix = "__Host-"
+
+// CookieConfig はセッションクッキーと CSRF トークンのクッキーの属性
+type CookieConfig struct {
+	SessionName string
+	CSRFName    string
+	Secure      bool
+	SameSite    http.SameSite
+	Domain      string
+}
+
+var Cookies CookieConfig
+
+// LoadCookieConfig は環境変数からクッキーの属性を読み込む。
+// Secure は平文 HTTP のローカル環境でもログインできるよう既定で無効にする。
+// SESSION_COOKIE_HOST_PREFIX=true の場合はクッキー名に __Host- を付け、
+// ブラウザが要求する Secure・Path=/・Domain なしを強制する。
+// セッションクッキーを読む他のサービスにも同じ環境変数を設定すること。
+func LoadCookieConfig() {
+	Cookies = CookieConfig{
+		SessionName: getEnv("SESSION_COOKIE_NAME", "session_id"),
+		CSRFName:    getEnv("CSRF_COOKIE_NAME", "csrf_token"),
+		Secure:      getBoolEnv("SESSION_COOKIE_SECURE", false),
+		SameSite:    parseSameSite(getEnv("SESSION_COOKIE_SAMESITE", "lax")),
+		Domain:      os.Getenv("SESSION_COOKIE_DOMAIN"),
+	}
+
+	if getBoolEnv("SESSION_COOKIE_HOST_PREFIX", false) {
+		if Cookies.Domain != "" {
+			log.Fatal("SESSION_COOKIE_DOMAIN cannot be used with SESSION_COOKIE_HOST_PREFIX")
+		}
+		Cookies.SessionName = hostPrefix + strings.TrimPrefix(Cookies.SessionName, hostPrefix)
+		Cookies.CSRFName = hostPrefix + strings.TrimPrefix(Cookies.CSRFName, hostPrefix)
+		if !Cookies.Secure {
+			log.Println("warning: SESSION_COOKIE_HOST_PREFIX forces SESSION_COOKIE_SECURE=true; cookies will not be sent over plain HTTP")
+			Cookies.Secure = true
+		}
+	}
+
+	// SameSite=None は Secure でないとブラウザに拒否される
+	if Cookies.SameSite == http.SameSiteNoneMode && !Cookies.Secure {
+		log.Fatal("SESSION_COOKIE_SAMESITE=none requires SESSION_COOKIE_SECURE=true")
+	}
+}
+
+// SessionCookie はセッションIDを HttpOnly クッキーにする
+func (c CookieConfig) SessionCookie(value string, expires time.Time) *http.Cookie {
+	return c.cookie(c.SessionName, value, expires, true)
+}
+
+// CSRFCookie は CSRF トークンをフロントエンドが読めるクッキーにする
+func (c CookieConfig) CSRFCookie(value string, expires time.Time) *http.Cookie {
+	return c.cookie(c.CSRFName, value, expires, false)
+}
+
+// ClearCookies はセッションと CSRF トークンのクッキーを削除するクッキーを返す
+func (c CookieConfig) ClearCookies() []*http.Cookie {
+	session := c.cookie(c.SessionName, "", time.Unix(0, 0), true)
+	session.MaxAge = -1
+	csrf := c.cookie(c.CSRFName, "", time.Unix(0, 0), false)
+	csrf.MaxAge = -1
+	return []*http.Cookie{session, csrf}
+}
+
+func (c CookieConfig) cookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   c.Domain,
+		Expires:  expires,
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+}
+
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	case "lax":
+		return http.SameSiteLaxMode
+	}
+	log.Fatalf("invalid value for SESSION_COOKIE_SAMESITE: %q", value)
+	return http.SameSiteDefaultMode
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("invalid value for %s: %v", key, err)
+	}
+	return b
+}
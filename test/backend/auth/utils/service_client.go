@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DB Pilot Service の署名ヘッダー
+const (
+	serviceTimestampHeader = "X-Service-Timestamp"
+	serviceSignatureHeader = "X-Service-Signature"
+)
+
+var serviceClient = &http.Client{Timeout: 10 * time.Second}
+
+// ServiceSignature は共有鍵でリクエストの署名を計算する。
+// 署名対象は「メソッド\nパス\nタイムスタンプ\nボディのSHA-256」で、DB Pilot Service と同じ計算をする。
+func ServiceSignature(secret []byte, method, path, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PostDBPilot は SERVICE_HMAC_SECRET で署名した JSON を DB Pilot Service の内部エンドポイントに送る
+func PostDBPilot(path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, os.Getenv("DB_PILOT_SERVICE_URL")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(serviceTimestampHeader, timestamp)
+	req.Header.Set(serviceSignatureHeader, ServiceSignature([]byte(os.Getenv("SERVICE_HMAC_SECRET")), http.MethodPost, req.URL.Path, timestamp, body))
+
+	return serviceClient.Do(req)
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+
 	"github.com/google/uuid"
 )
 
@@ -8,3 +11,12 @@ import (
 func GenerateSessionID() string {
 	return uuid.New().String()
 }
+
+// GenerateCSRFToken はセッションに結び付ける CSRF トークンを生成する
+func GenerateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
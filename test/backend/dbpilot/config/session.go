@@ -0,0 +1,111 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const hostPrefix = "__Host-"
+
+// SessionConfig はセッションの有効期限の設定
+type SessionConfig struct {
+	// セッションIDを格納するクッキーの名前（認証サービスの SESSION_COOKIE_NAME と
+	// SESSION_COOKIE_HOST_PREFIX から同じ規則で決める）
+	CookieName string
+	// 最後のアクセスからこの時間が経つとセッションは失効する
+	IdleTimeout time.Duration
+	// ログインからこの時間が経つとアクセスの有無に関わらず失効する
+	MaxLifetime time.Duration
+	// 失効までの残り時間がこれを下回ったアクセスで有効期限を延長する
+	RenewWithin time.Duration
+	// 失効したセッションを削除する間隔と1回の削除件数
+	CleanupInterval  time.Duration
+	CleanupBatchSize int
+}
+
+var Session SessionConfig
+
+// LoadSessionConfig は環境変数からセッションの設定を読み込む
+func LoadSessionConfig() {
+	Session = SessionConfig{
+		CookieName:       SessionCookieName(),
+		IdleTimeout:      getDurationEnv("SESSION_IDLE_TIMEOUT", 2*time.Hour),
+		MaxLifetime:      getDurationEnv("SESSION_MAX_LIFETIME", 24*time.Hour),
+		CleanupInterval:  getDurationEnv("SESSION_CLEANUP_INTERVAL", 10*time.Minute),
+		CleanupBatchSize: 1000,
+	}
+	Session.RenewWithin = getDurationEnv("SESSION_RENEW_WITHIN", Session.IdleTimeout/2)
+}
+
+// SessionCookieName は認証サービスが発行するセッションクッキーの名前を返す。
+// SESSION_COOKIE_HOST_PREFIX=true の場合は認証サービスと同じく __Host- を付ける。
+func SessionCookieName() string {
+	name := getEnv("SESSION_COOKIE_NAME", "session_id")
+	if getBoolEnv("SESSION_COOKIE_HOST_PREFIX", false) {
+		name = hostPrefix + strings.TrimPrefix(name, hostPrefix)
+	}
+	return name
+}
+
+// NewExpiry は新しいセッションのアイドル期限と絶対期限を返す
+func (s SessionConfig) NewExpiry(now time.Time) (time.Time, time.Time) {
+	absolute := now.Add(s.MaxLifetime)
+	return minTime(now.Add(s.IdleTimeout), absolute), absolute
+}
+
+// RenewedExpiry はアクセス時に延長したアイドル期限を返す。延長が不要な場合は false を返す。
+// 延長しても絶対期限は超えない。絶対期限を持たないセッションは延長しない。
+func (s SessionConfig) RenewedExpiry(expiresAt, absoluteExpiresAt, now time.Time) (time.Time, bool) {
+	if absoluteExpiresAt.IsZero() || expiresAt.Sub(now) > s.RenewWithin {
+		return expiresAt, false
+	}
+
+	renewed := minTime(now.Add(s.IdleTimeout), absoluteExpiresAt)
+	if !renewed.After(expiresAt) {
+		return expiresAt, false
+	}
+	return renewed, true
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid value for %s: %v", key, err)
+	}
+	return d
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("invalid value for %s: %v", key, err)
+	}
+	return b
+}
@@ -21,20 +21,11 @@ type ProfileResponse struct {
 	ImageURL string `json:"image_url"`
 }
 
-// RegisterProfile はセッションからUserIDを取得し、プロフィールを登録します
+// RegisterProfile は呼び出し元のユーザーのプロフィールを登録します
 func RegisterProfile(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// CookieからセッションIDを取得
-		sessionID, err := c.Cookie("session_id")
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found"})
-			return
-		}
-
-		// セッションIDからUserIDを取得
-		var session models.Session
-		if err := db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+		principal, ok := currentPrincipal(c)
+		if !ok {
 			return
 		}
 
@@ -46,7 +37,7 @@ func RegisterProfile(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// プロフィールの登録
-		profile := models.Profile{UserID: session.UserID, Name: req.Name, ImageURL: req.ImageURL}
+		profile := models.Profile{UserID: principal.UserID, Name: req.Name, ImageURL: req.ImageURL}
 		if err := db.Create(&profile).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create profile"})
 			return
@@ -56,26 +47,17 @@ func RegisterProfile(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// GetProfile はセッションIDを使ってユーザーのプロフィール情報を取得します
+// GetProfile は呼び出し元のユーザーのプロフィール情報を取得します
 func GetProfile(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// CookieからセッションIDを取得
-		sessionID, err := c.Cookie("session_id")
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found"})
-			return
-		}
-
-		// セッションIDからユーザー情報を取得
-		var session models.Session
-		if err := db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+		principal, ok := currentPrincipal(c)
+		if !ok {
 			return
 		}
 
 		// ユーザーとそのプロフィール情報を取得
 		var user models.User
-		if err := db.Preload("Profile").Where("id = ?", session.UserID).First(&user).Error; err != nil {
+		if err := db.Preload("Profile").Where("id = ?", principal.UserID).First(&user).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User or profile not found"})
 			return
 		}
@@ -2,21 +2,57 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"time"
 
+	"dbpilot/config"
+	"dbpilot/middleware"
 	"dbpilot/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type CreateSessionRequest struct {
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	SessionID string `json:"session_id"`
+	CSRFToken string `json:"csrf_token"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+}
+
+type DeleteSessionRequest struct {
+	SessionID  string `json:"session_id"`
+	CSRFToken  string `json:"csrf_token"`
+	AllDevices bool   `json:"all_devices"`
+}
+
+type IntrospectSessionRequest struct {
+	SessionID string `json:"session_id"`
+}
+
+// IntrospectSessionResponse は有効なセッションの呼び出し元情報
+type IntrospectSessionResponse struct {
+	Active    bool      `json:"active"`
 	UserID    uint      `json:"user_id"`
 	Email     string    `json:"email"`
-	SessionID string    `json:"session_id"`
+	Roles     []string  `json:"roles"`
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// SessionResponse は一覧で返すセッション情報。セッションIDそのものは返さない。
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
 // CreateSession は新しいセッションをDBに保存します
 func CreateSession(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -26,18 +62,186 @@ func CreateSession(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 有効期限はセッションの設定に従って決める
+		now := time.Now()
+		expiresAt, absoluteExpiresAt := config.Session.NewExpiry(now)
+
 		// セッション情報を保存
 		session := models.Session{
-			UserID:    req.UserID,
-			Email:     req.Email,
-			SessionID: req.SessionID,
-			ExpiresAt: req.ExpiresAt,
+			UserID:            req.UserID,
+			Email:             req.Email,
+			SessionID:         req.SessionID,
+			CSRFToken:         req.CSRFToken,
+			UserAgent:         req.UserAgent,
+			IP:                req.IP,
+			LastSeenAt:        now,
+			ExpiresAt:         expiresAt,
+			AbsoluteExpiresAt: absoluteExpiresAt,
 		}
 		if err := db.Create(&session).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Session created successfully"})
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Session created successfully",
+			"expires_at":          session.ExpiresAt,
+			"absolute_expires_at": session.AbsoluteExpiresAt,
+		})
+	}
+}
+
+// DeleteSession はログアウト時にセッションを削除します。
+// all_devices が true の場合は同じユーザーの全てのセッションを削除します。
+func DeleteSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DeleteSessionRequest
+		if err := c.ShouldBindJSON(&req); err != nil || req.SessionID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		var session models.Session
+		if err := db.Where("session_id = ?", req.SessionID).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// 既に削除済みのセッションはログアウト済みとして扱う
+				c.JSON(http.StatusOK, gin.H{"message": "Session deleted successfully", "deleted": 0})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+			return
+		}
+
+		// 第三者のサイトから強制的にログアウトさせられないよう CSRF トークンを照合する
+		if session.CSRFToken != "" && subtle.ConstantTimeCompare([]byte(req.CSRFToken), []byte(session.CSRFToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token missing or invalid", "code": "csrf_token_invalid"})
+			return
+		}
+
+		query := db.Where("id = ?", session.ID)
+		if req.AllDevices {
+			query = db.Where("user_id = ?", session.UserID)
+		}
+		result := query.Delete(&models.Session{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session deleted successfully", "deleted": result.RowsAffected})
+	}
+}
+
+// IntrospectSession は他のサービスから渡されたセッションIDを検証し、呼び出し元の情報を返します。
+// VerifySession と同じくアクセスとして扱い、アイドル期限を延長します。
+func IntrospectSession(db *gorm.DB, lastSeen *middleware.LastSeenTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req IntrospectSessionRequest
+		if err := c.ShouldBindJSON(&req); err != nil || req.SessionID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		session, principal, err := middleware.ResolveSession(db, lastSeen, req.SessionID, time.Now())
+		if errors.Is(err, middleware.ErrSessionInvalid) || errors.Is(err, middleware.ErrSessionExpired) {
+			c.JSON(http.StatusUnauthorized, gin.H{"active": false, "error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+			return
+		}
+
+		c.JSON(http.StatusOK, IntrospectSessionResponse{
+			Active:    true,
+			UserID:    principal.UserID,
+			Email:     principal.Email,
+			Roles:     principal.Roles,
+			ExpiresAt: session.ExpiresAt,
+		})
+	}
+}
+
+// currentPrincipal は VerifySession が設定した呼び出し元を返します
+func currentPrincipal(c *gin.Context) (*middleware.Principal, bool) {
+	principal, ok := middleware.CurrentPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+	return principal, true
+}
+
+// ListSessions はログイン中のユーザーの有効なセッション一覧を返します
+func ListSessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current, ok := currentPrincipal(c)
+		if !ok {
+			return
+		}
+
+		var sessions []models.Session
+		if err := db.Where("user_id = ? AND expires_at > ?", current.UserID, time.Now()).
+			Order("last_seen_at DESC").
+			Find(&sessions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+			return
+		}
+
+		response := make([]SessionResponse, 0, len(sessions))
+		for _, s := range sessions {
+			response = append(response, SessionResponse{
+				ID:         s.ID,
+				UserAgent:  s.UserAgent,
+				IP:         s.IP,
+				CreatedAt:  s.CreatedAt,
+				LastSeenAt: s.LastSeenAt,
+				ExpiresAt:  s.ExpiresAt,
+				Current:    s.ID == current.SessionID,
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{"sessions": response})
+	}
+}
+
+// RevokeSession は指定した自分のセッションを削除します
+func RevokeSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current, ok := currentPrincipal(c)
+		if !ok {
+			return
+		}
+
+		// 他のユーザーのセッションは削除できない
+		result := db.Where("id = ? AND user_id = ?", c.Param("id"), current.UserID).Delete(&models.Session{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+	}
+}
+
+// RevokeOtherSessions は現在のセッション以外の自分のセッションを全て削除します
+func RevokeOtherSessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		current, ok := currentPrincipal(c)
+		if !ok {
+			return
+		}
+
+		result := db.Where("user_id = ? AND id <> ?", current.UserID, current.SessionID).Delete(&models.Session{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked successfully", "revoked": result.RowsAffected})
 	}
 }
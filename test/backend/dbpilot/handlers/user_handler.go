@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"dbpilot/models"
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -18,11 +20,24 @@ type QueryUserRequest struct {
 }
 
 type QueryUserResponse struct {
-	ID       uint   `json:"id"`
+	ID    uint   `json:"id"`
+	Email string `json:"email"`
+}
+
+type VerifyCredentialsRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
 
+type VerifyCredentialsResponse struct {
+	Match  bool   `json:"match"`
+	UserID uint   `json:"user_id,omitempty"`
+	Email  string `json:"email,omitempty"`
+}
+
+// dummyPasswordHash は存在しないユーザーでも照合にかかる時間を揃えるためのハッシュ
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
 func SaveUser(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req UserRequest
@@ -56,11 +71,40 @@ func QueryUser(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// ユーザー情報をレスポンスとして返す（パスワードはハッシュ化されたもの）
+		// ユーザー情報をレスポンスとして返す（パスワードハッシュは返さない）
 		c.JSON(http.StatusOK, QueryUserResponse{
-			ID:       user.ID,
-			Email:    user.Email,
-			Password: user.Password,
+			ID:    user.ID,
+			Email: user.Email,
 		})
 	}
 }
+
+// VerifyCredentials はメールアドレスとパスワードを照合し、一致したかどうかだけを返します
+func VerifyCredentials(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req VerifyCredentialsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+
+		var user models.User
+		if err := db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify credentials"})
+				return
+			}
+			// ユーザーの有無を応答時間から推測されないようにする
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+			c.JSON(http.StatusOK, VerifyCredentialsResponse{Match: false})
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			c.JSON(http.StatusOK, VerifyCredentialsResponse{Match: false})
+			return
+		}
+
+		c.JSON(http.StatusOK, VerifyCredentialsResponse{Match: true, UserID: user.ID, Email: user.Email})
+	}
+}
@@ -0,0 +1,65 @@
+package janitor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"dbpilot/models"
+	"gorm.io/gorm"
+)
+
+// SessionJanitor は失効したセッションを定期的に削除する
+type SessionJanitor struct {
+	db        *gorm.DB
+	interval  time.Duration
+	batchSize int
+}
+
+func NewSessionJanitor(db *gorm.DB, interval time.Duration, batchSize int) *SessionJanitor {
+	return &SessionJanitor{db: db, interval: interval, batchSize: batchSize}
+}
+
+// Run は ctx が終了するまで interval ごとに削除する
+func (j *SessionJanitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := j.Sweep(ctx, time.Now())
+			if err != nil && ctx.Err() == nil {
+				log.Printf("session janitor: %v", err)
+			}
+			if removed > 0 {
+				log.Printf("session janitor: removed %d expired sessions", removed)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sweep は now の時点で失効しているセッションを batchSize 件ずつ削除し、削除した件数を返す。
+// 一度に大量の行をロックしないよう、バッチごとに別の文で削除する。
+func (j *SessionJanitor) Sweep(ctx context.Context, now time.Time) (int64, error) {
+	var total int64
+	for {
+		// アイドル期限は絶対期限を超えないため expires_at だけで判定できる
+		result := j.db.WithContext(ctx).
+			Where("id IN (?)", j.db.Model(&models.Session{}).
+				Select("id").
+				Where("expires_at < ?", now).
+				Limit(j.batchSize)).
+			Delete(&models.Session{})
+		if result.Error != nil {
+			return total, result.Error
+		}
+		total += result.RowsAffected
+
+		if result.RowsAffected < int64(j.batchSize) || ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+	}
+}
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"dbpilot/config"
 	"dbpilot/handlers"
+	"dbpilot/janitor"
 	"dbpilot/middleware"
 	"dbpilot/models"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -18,16 +27,55 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	// セッション設定の読み込み
+	config.LoadSessionConfig()
+
+	// サービス間認証の共有鍵
+	serviceSecret := []byte(os.Getenv("SERVICE_HMAC_SECRET"))
+	if len(serviceSecret) == 0 {
+		log.Fatal("SERVICE_HMAC_SECRET is not set")
+	}
+
 	// データベース接続
 	config.ConnectDatabase()
 	db := config.DB
 
 	// マイグレーション
 	db.AutoMigrate(&models.User{}, &models.Profile{}, &models.Session{}, &models.Incident{}, &models.Response{}, &models.IncidentRelation{})
+	// 絶対期限を導入する前のセッションは、現在のアイドル期限を絶対期限とする
+	if err := db.Model(&models.Session{}).
+		Where("absolute_expires_at IS NULL OR absolute_expires_at = ?", time.Time{}).
+		Update("absolute_expires_at", gorm.Expr("expires_at")).Error; err != nil {
+		log.Fatalf("Failed to backfill session absolute expiry: %v", err)
+	}
+
+	// SIGINT/SIGTERM でバックグラウンド処理とサーバーを停止する
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+
+	// セッションの最終アクセス時刻はまとめて書き込む。
+	// 処理中のリクエストの記録も書き込めるよう、サーバー停止後に止める。
+	lastSeen := middleware.NewLastSeenTracker(db, time.Minute)
+	lastSeenCtx, stopLastSeen := context.WithCancel(context.Background())
+	defer stopLastSeen()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		lastSeen.Run(lastSeenCtx)
+	}()
+
+	// 失効したセッションの削除
+	sessionJanitor := janitor.NewSessionJanitor(db, config.Session.CleanupInterval, config.Session.CleanupBatchSize)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		sessionJanitor.Run(ctx)
+	}()
 
 	r := gin.Default()
 	protected := r.Group("/")
-	protected.Use(middleware.VerifySession(db)) // ミドルウェア適用
+	protected.Use(middleware.VerifySession(db, lastSeen), middleware.RequireCSRFToken()) // ミドルウェア適用
 	{
 		protected.POST("/profile", handlers.RegisterProfile(db))
 		protected.GET("/get-profile", handlers.GetProfile(db))
@@ -39,11 +87,25 @@ func main() {
 
 		// responses
 		protected.POST("/responses", handlers.CreateResponse(db))
+
+		// sessions
+		protected.GET("/sessions", handlers.ListSessions(db))
+		protected.DELETE("/sessions/:id", handlers.RevokeSession(db))
+		protected.POST("/sessions/revoke-others", handlers.RevokeOtherSessions(db))
 	}
+	// サービス間の内部エンドポイント（共有鍵による署名が必要）
+	internal := r.Group("/")
+	internal.Use(middleware.RequireServiceSignature(serviceSecret))
+	{
+		internal.POST("/create-user", handlers.SaveUser(db))
+		internal.POST("/create-session", handlers.CreateSession(db))
+		internal.POST("/delete-session", handlers.DeleteSession(db))
+		internal.POST("/introspect-session", handlers.IntrospectSession(db, lastSeen))
+		internal.POST("/queryUser", handlers.QueryUser(db))
+		internal.POST("/verify-credentials", handlers.VerifyCredentials(db))
+	}
+
 	// エンドポイント設定
-	r.POST("/create-user", handlers.SaveUser(db))
-	r.POST("/create-session", handlers.CreateSession(db))
-	r.POST("/queryUser", handlers.QueryUser(db))
 	r.POST("/incidents", handlers.CreateIncident(db))
 
 	// サーバー起動
@@ -52,5 +114,24 @@ func main() {
 		serverPort = "3002" // デフォルトポート
 	}
 
-	r.Run(fmt.Sprintf(":%s", serverPort))
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", serverPort),
+		Handler: r,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+	stopLastSeen()
+	workers.Wait()
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"dbpilot/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CSRFHeader はフロントエンドが CSRF トークンを送るヘッダー
+	CSRFHeader = "X-CSRF-Token"
+
+	sessionGinKey = "session"
+)
+
+// RequireCSRFToken は状態を変更するリクエストの X-CSRF-Token ヘッダーを
+// ログイン時にセッションへ保存したトークンと照合するミドルウェア（シンクロナイザートークン方式）。
+// VerifySession の後に適用し、GET などの安全なメソッドは検査しない。
+func RequireCSRFToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			c.Next()
+			return
+		}
+
+		value, _ := c.Get(sessionGinKey)
+		session, ok := value.(*models.Session)
+		token := c.GetHeader(CSRFHeader)
+		if !ok || session.CSRFToken == "" || token == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "CSRF token missing or invalid", "code": "csrf_token_invalid"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"dbpilot/models"
+	"gorm.io/gorm"
+)
+
+// LastSeenTracker はセッションの最終アクセス時刻の書き込みをまとめる。
+// リクエストごとには DB に書かず、interval ごとに溜まった分だけを更新する。
+type LastSeenTracker struct {
+	db       *gorm.DB
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[uint]time.Time
+}
+
+func NewLastSeenTracker(db *gorm.DB, interval time.Duration) *LastSeenTracker {
+	return &LastSeenTracker{db: db, interval: interval, pending: make(map[uint]time.Time)}
+}
+
+// Touch はセッションへのアクセスを記録する。
+// 保存済みの時刻が interval 以内なら何もしない。
+func (t *LastSeenTracker) Touch(session *models.Session, now time.Time) {
+	if now.Sub(session.LastSeenAt) < t.interval {
+		return
+	}
+
+	t.mu.Lock()
+	t.pending[session.ID] = now
+	t.mu.Unlock()
+}
+
+// Run は ctx が終了するまで定期的に書き込み、終了時に残りを書き込む
+func (t *LastSeenTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.Flush()
+		case <-ctx.Done():
+			t.Flush()
+			return
+		}
+	}
+}
+
+// Flush は溜まっている最終アクセス時刻を DB に書き込む
+func (t *LastSeenTracker) Flush() {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[uint]time.Time)
+	t.mu.Unlock()
+
+	for id, seenAt := range pending {
+		if err := t.db.Model(&models.Session{}).
+			Where("id = ? AND last_seen_at < ?", id, seenAt).
+			Update("last_seen_at", seenAt).Error; err != nil {
+			log.Printf("failed to update last_seen_at for session %d: %v", id, err)
+		}
+	}
+}
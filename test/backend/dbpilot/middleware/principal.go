@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Principal はセッションから特定したリクエストの呼び出し元
+type Principal struct {
+	UserID uint
+	Email  string
+	Roles  []string
+	// 呼び出し元のセッション（sessions テーブルの ID）
+	SessionID uint
+}
+
+// HasRole は呼び出し元が指定のロールを持つか判定します
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+const principalGinKey = "principal"
+
+type principalContextKey struct{}
+
+// WithPrincipal は呼び出し元を context.Context に格納します
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext は context.Context から呼び出し元を取り出します。
+// GraphQL のリゾルバーなど gin.Context を持たない処理から使います。
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok
+}
+
+// CurrentPrincipal は VerifySession が設定した呼び出し元を返します
+func CurrentPrincipal(c *gin.Context) (*Principal, bool) {
+	if v, ok := c.Get(principalGinKey); ok {
+		p, ok := v.(*Principal)
+		return p, ok
+	}
+	return PrincipalFromContext(c.Request.Context())
+}
+
+// setPrincipal は呼び出し元を gin.Context とリクエストの context.Context の両方に設定します
+func setPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalGinKey, p)
+	c.Request = c.Request.WithContext(WithPrincipal(c.Request.Context(), p))
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ServiceTimestampHeader と ServiceSignatureHeader はサービス間リクエストの署名ヘッダー
+	ServiceTimestampHeader = "X-Service-Timestamp"
+	ServiceSignatureHeader = "X-Service-Signature"
+
+	// 署名の時刻と受信時刻のずれの許容範囲
+	serviceSignatureMaxSkew = 5 * time.Minute
+)
+
+// ServiceSignature は共有鍵でリクエストの署名を計算する。
+// 署名対象は「メソッド\nパス\nタイムスタンプ\nボディのSHA-256」で、認証サービスと同じ計算をする。
+func ServiceSignature(secret []byte, method, path, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RequireServiceSignature は内部エンドポイントへのリクエストが共有鍵で署名されているか検証するミドルウェア
+func RequireServiceSignature(secret []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timestamp := c.GetHeader(ServiceTimestampHeader)
+		signature := c.GetHeader(ServiceSignatureHeader)
+		if timestamp == "" || signature == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Service signature required", "code": "service_signature_required"})
+			c.Abort()
+			return
+		}
+
+		// 古い署名の再利用を防ぐため時刻のずれを確認する
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service signature", "code": "service_signature_invalid"})
+			c.Abort()
+			return
+		}
+		if skew := time.Since(time.Unix(unix, 0)); skew > serviceSignatureMaxSkew || skew < -serviceSignatureMaxSkew {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Service signature expired", "code": "service_signature_expired"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			c.Abort()
+			return
+		}
+		// ハンドラーが再度読めるようにボディを戻す
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		expected := ServiceSignature(secret, c.Request.Method, c.Request.URL.Path, timestamp, body)
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service signature", "code": "service_signature_invalid"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,40 +1,99 @@
 package middleware
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
+	"dbpilot/config"
 	"dbpilot/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrSessionInvalid = errors.New("invalid session")
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// ResolveSession はセッションIDからセッションとユーザーを読み込み、有効期限を確認する。
+// 有効なセッションはアイドル期限を延長し、最終アクセス時刻を記録する。
+func ResolveSession(db *gorm.DB, lastSeen *LastSeenTracker, sessionID string, now time.Time) (*models.Session, *Principal, error) {
+	// セッション情報をデータベースから取得
+	var session models.Session
+	if err := db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrSessionInvalid
+		}
+		return nil, nil, err
+	}
+
+	// 有効期限確認（アイドル期限と絶対期限）
+	if session.Expired(now) {
+		return nil, nil, ErrSessionExpired
+	}
+
+	// 失効が近ければアイドル期限を延長する
+	if renewed, ok := config.Session.RenewedExpiry(session.ExpiresAt, session.AbsoluteExpiresAt, now); ok {
+		if err := db.Model(&session).Update("expires_at", renewed).Error; err != nil {
+			log.Printf("failed to renew session %d: %v", session.ID, err)
+		} else {
+			session.ExpiresAt = renewed
+		}
+	}
+
+	// セッションのユーザーを取得
+	var user models.User
+	if err := db.First(&user, session.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrSessionInvalid
+		}
+		return nil, nil, err
+	}
+
+	// 最終アクセス時刻の記録（書き込みはまとめて行う）
+	lastSeen.Touch(&session, now)
+
+	return &session, &Principal{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Roles:     []string{user.Role},
+		SessionID: session.ID,
+	}, nil
+}
+
 // セッション有効性を確認するミドルウェア
-func VerifySession(db *gorm.DB) gin.HandlerFunc {
+func VerifySession(db *gorm.DB, lastSeen *LastSeenTracker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// CookieからセッションIDを取得
-		sessionID, err := c.Cookie("session_id")
+		sessionID, err := c.Cookie(config.Session.CookieName)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session not found"})
 			c.Abort() // 次の処理を中止
 			return
 		}
 
-		// セッション情報をデータベースから取得
-		var session models.Session
-		if err := db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		session, principal, err := ResolveSession(db, lastSeen, sessionID, time.Now())
+		switch {
+		case errors.Is(err, ErrSessionExpired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired"})
+			c.Abort()
+			return
+		case errors.Is(err, ErrSessionInvalid):
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
 			c.Abort()
 			return
-		}
-
-		// 有効期限確認
-		if time.Now().After(session.ExpiresAt) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired"})
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
 			c.Abort()
 			return
 		}
 
+		// 呼び出し元をハンドラーから参照できるようにする
+		c.Set(sessionGinKey, session)
+		setPrincipal(c, principal)
+
 		// セッションが有効な場合はリクエストを次に進める
 		c.Next()
 	}
@@ -6,6 +6,7 @@ type User struct {
 	ID       uint   `gorm:"primaryKey"`
 	Email    string `gorm:"unique"`
 	Password string
+	Role     string  `gorm:"size:50;not null;default:member"`
 	Profile  Profile `gorm:"foreignKey:UserID"` // ユーザーとプロフィールの関連付け
 }
 
@@ -18,10 +19,27 @@ type Profile struct {
 
 type Session struct {
 	ID        uint `gorm:"primaryKey"`
-	UserID    uint
+	UserID    uint `gorm:"index"`
 	Email     string
 	SessionID string `gorm:"unique"`
-	ExpiresAt time.Time
+	// 状態を変更するリクエストで X-CSRF-Token ヘッダーと照合するトークン
+	CSRFToken  string `gorm:"size:64"`
+	UserAgent  string `gorm:"size:512"`
+	IP         string `gorm:"size:64"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// アイドル期限。アクセスがあると AbsoluteExpiresAt を上限に延長される
+	ExpiresAt         time.Time `gorm:"index"`
+	AbsoluteExpiresAt time.Time
+}
+
+// Expired はセッションが失効しているか判定します
+func (s *Session) Expired(now time.Time) bool {
+	if now.After(s.ExpiresAt) {
+		return true
+	}
+	// 絶対期限を持たないセッションは延長されないため、ExpiresAt が絶対期限を兼ねる
+	return !s.AbsoluteExpiresAt.IsZero() && now.After(s.AbsoluteExpiresAt)
 }
 
 // incidents テーブル
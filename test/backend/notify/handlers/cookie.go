@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+const hostPrefix = "__Host-"
+
+// sessionCookieName は認証サービスが発行するセッションクッキーの名前を返す。
+// SESSION_COOKIE_HOST_PREFIX=true の場合は認証サービスと同じく __Host- を付ける。
+func sessionCookieName() string {
+	name := os.Getenv("SESSION_COOKIE_NAME")
+	if name == "" {
+		name = "session_id"
+	}
+	if prefixed, _ := strconv.ParseBool(os.Getenv("SESSION_COOKIE_HOST_PREFIX")); prefixed {
+		name = hostPrefix + strings.TrimPrefix(name, hostPrefix)
+	}
+	return name
+}
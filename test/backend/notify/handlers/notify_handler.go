@@ -4,6 +4,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"os"
 	"time"
@@ -25,8 +26,9 @@ func NotifyHandler(c *gin.Context) {
 		return
 	}
 
-	// クッキーからsession_idを取得
-	sessionID, err := c.Cookie("session_id")
+	// クッキーからセッションIDを取得（クッキー名は認証サービスと揃える）
+	cookieName := sessionCookieName()
+	sessionID, err := c.Cookie(cookieName)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session ID not found"})
 		return
@@ -37,11 +39,12 @@ func NotifyHandler(c *gin.Context) {
 	dbReq, _ := json.Marshal(req)
 	dbReqBody := bytes.NewBuffer(dbReq)
 
-	// DB Pilotへのリクエストにsession_idをヘッダーとして追加
+	// DB Pilotへのリクエストにセッションのクッキーと CSRF トークンをそのまま引き継ぐ
 	dbClient := &http.Client{}
 	dbRequest, _ := http.NewRequest("POST", dbPilotURL, dbReqBody)
 	dbRequest.Header.Set("Content-Type", "application/json")
-	dbRequest.Header.Set("Cookie", "session_id="+sessionID)
+	dbRequest.AddCookie(&http.Cookie{Name: cookieName, Value: sessionID})
+	dbRequest.Header.Set("X-CSRF-Token", c.GetHeader("X-CSRF-Token"))
 
 	dbResp, err := dbClient.Do(dbRequest)
 	dbPilotStatus := "Success"
@@ -49,6 +52,15 @@ func NotifyHandler(c *gin.Context) {
 		dbPilotStatus = "Failed"
 	}
 
+	// 認証・CSRF の検証に失敗したリクエストでは Teams に通知しない
+	if err == nil && (dbResp.StatusCode == http.StatusUnauthorized || dbResp.StatusCode == http.StatusForbidden) {
+		defer dbResp.Body.Close()
+		c.Status(dbResp.StatusCode)
+		c.Header("Content-Type", "application/json")
+		io.Copy(c.Writer, dbResp.Body)
+		return
+	}
+
 	// 2. Teams Webhookに通知を送信
 	teamsWebhookURL := os.Getenv("TEAMS_WEBHOOK_URL")
 	teamsReq := map[string]interface{}{